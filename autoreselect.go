@@ -0,0 +1,85 @@
+package htmxheaders
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AutoReselect returns a http.Handler for handlers that always render the full page, setting HX-Reselect on
+// responses to htmx requests so that htmx extracts the part of the page matching the request's HX-Target.
+// It is an alternative to BlockRenderer, applied per route by wrapping the handlers that need it.
+//
+// The selector is looked up by HX-Target id in selectors, which may be nil, and is otherwise the id as a CSS id
+// selector. HX-Reselect is only set on successful responses to htmx requests with a target that are neither
+// boosted nor history restore requests, and is never set when the handler has already set HX-Reselect itself.
+//
+// Example usage:
+//
+//	mux.Handle("GET /cart", hh.AutoReselect(cartPage, map[string]string{"cart-summary": "#summary"}))
+func AutoReselect(next http.Handler, selectors map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := ParseRequest(r)
+		if !req.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addVary(w.Header(), "HX-Target")
+		if req.Boosted || req.HistoryRestoreRequest || req.Target == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		selector, ok := selectors[req.Target]
+		if !ok {
+			selector = "#" + cssEscapeIdent(req.Target)
+		}
+
+		sw := newStatusWriter(w)
+		sw.beforeWriteHeader = func(status int) {
+			if status < 200 || status >= 300 || hasHeader(sw.Header(), "HX-Reselect") {
+				return
+			}
+			sw.Header().Set("HX-Reselect", selector)
+		}
+
+		next.ServeHTTP(sw, r)
+		sw.finish()
+	})
+}
+
+// hasHeader reports whether the header is present, even with an empty value or a non-canonical key
+// set by assigning to the http.Header map directly.
+func hasHeader(h http.Header, name string) bool {
+	for key := range h {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cssEscapeIdent escapes an id for use in a CSS selector, following the CSS.escape algorithm.
+// https://drafts.csswg.org/cssom/#serialize-an-identifier
+func cssEscapeIdent(id string) string {
+	var sb strings.Builder
+	for i, r := range id {
+		switch {
+		case r == 0:
+			sb.WriteRune('�')
+		case r < 0x20 || r == 0x7f,
+			i == 0 && r >= '0' && r <= '9',
+			i == 1 && r >= '0' && r <= '9' && id[0] == '-':
+			fmt.Fprintf(&sb, "\\%x ", r)
+		case i == 0 && r == '-' && len(id) == 1:
+			sb.WriteString(`\-`)
+		case r >= 0x80 || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
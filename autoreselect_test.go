@@ -0,0 +1,79 @@
+package htmxheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func serveAutoReselect(handler http.Handler, selectors map[string]string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/cart", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	hh.AutoReselect(handler, selectors).ServeHTTP(w, r)
+	return w
+}
+
+func TestAutoReselect(t *testing.T) {
+	page := named("<html>...</html>")
+	selectors := map[string]string{"cart-summary": "#summary"}
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"target id", map[string]string{"HX-Request": "true", "HX-Target": "cart"}, "#cart"},
+		{"mapped target", map[string]string{"HX-Request": "true", "HX-Target": "cart-summary"}, "#summary"},
+		{"escaped id", map[string]string{"HX-Request": "true", "HX-Target": "1st.item"}, `#\31 st\.item`},
+		{"not htmx", map[string]string{"HX-Target": "cart"}, ""},
+		{"no target", map[string]string{"HX-Request": "true"}, ""},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-Target": "cart"}, ""},
+	}
+
+	for _, test := range tests {
+		w := serveAutoReselect(page, selectors, test.headers)
+		assert.Equal(t, test.expected, w.Header().Get("HX-Reselect"), test.name)
+		assert.Equal(t, "<html>...</html>", w.Body.String(), test.name)
+	}
+}
+
+func TestAutoReselectKeepsExplicitReselect(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w, hh.Reselect("#other"))
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	})
+	w := serveAutoReselect(handler, nil, map[string]string{"HX-Request": "true", "HX-Target": "cart"})
+	assert.Equal(t, "#other", w.Header().Get("HX-Reselect"))
+
+	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["HX-Reselect"] = []string{""}
+	})
+	w = serveAutoReselect(handler, nil, map[string]string{"HX-Request": "true", "HX-Target": "cart"})
+	assert.Empty(t, w.Header().Get("HX-Reselect"))
+}
+
+func TestAutoReselectSkipsErrors(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	w := serveAutoReselect(handler, nil, map[string]string{"HX-Request": "true", "HX-Target": "cart"})
+
+	assert.Empty(t, w.Header().Get("HX-Reselect"))
+}
+
+func TestAutoReselectWithoutWrite(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	w := serveAutoReselect(handler, nil, map[string]string{"HX-Request": "true", "HX-Target": "cart"})
+
+	assert.Equal(t, "#cart", w.Header().Get("HX-Reselect"))
+}
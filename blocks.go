@@ -0,0 +1,90 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// BlockRenderer renders a whole page for normal requests, and only the block of the page matching the
+// request's HX-Target for htmx requests, so a single template set drives both full pages and their fragments.
+//
+// For an htmx request, the block is found by looking up the HX-Target id in Blocks, or using the id itself as
+// the block name if it is not mapped. Boosted requests, history restore requests, and htmx requests whose target
+// matches no block are rendered as the whole page.
+type BlockRenderer struct {
+	Template *template.Template // the template set containing the page and its blocks
+	Page     string             // the name of the template rendered as the whole page, Template itself if empty
+	Blocks   map[string]string  // maps HX-Target ids to the names of the blocks rendered for them
+	ETag     bool               // serve responses with ServeFragment, answering conditional requests with 304 Not Modified
+}
+
+// NewBlockRenderer returns a BlockRenderer rendering the template with the given name as the whole page.
+//
+// Example usage:
+//
+//	tmpl := template.Must(template.ParseFiles("cart.html")) // contains {{block "cart" .}}...{{end}}
+//	cart := hh.NewBlockRenderer(tmpl, "cart.html")
+//	cart.Blocks = map[string]string{"cart-summary": "summary"}
+//
+//	mux.HandleFunc("GET /cart", func(w http.ResponseWriter, r *http.Request) {
+//	    if err := cart.Render(w, r, loadCart(r)); err != nil {
+//	        // Handle error
+//	    }
+//	})
+func NewBlockRenderer(t *template.Template, page string) *BlockRenderer {
+	return &BlockRenderer{Template: t, Page: page}
+}
+
+// Block returns the name of the block rendered for the request, or an empty string if the whole page is rendered.
+func (b *BlockRenderer) Block(r *http.Request) string {
+	req := ParseRequest(r)
+	if !req.Enabled || req.Boosted || req.HistoryRestoreRequest || req.Target == "" {
+		return ""
+	}
+
+	name := req.Target
+	if mapped, ok := b.Blocks[req.Target]; ok {
+		name = mapped
+	}
+	if b.Template.Lookup(name) == nil {
+		return ""
+	}
+	return name
+}
+
+// Render writes the whole page, or the block matching the request's HX-Target, executed with data.
+// The template is executed into a buffer, so nothing is written if it returns an error.
+// HX-Request and HX-Target are added to the Vary header, as the response depends on them.
+func (b *BlockRenderer) Render(w http.ResponseWriter, r *http.Request, data any) error {
+	name := b.Block(r)
+	if name == "" {
+		name = b.Page
+	}
+	if name == "" {
+		name = b.Template.Name()
+	}
+	render := func(w io.Writer) error {
+		return b.Template.ExecuteTemplate(w, name, data)
+	}
+
+	if b.ETag {
+		return ServeFragment(w, r, render)
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return fmt.Errorf("error rendering template %q: %w", name, err)
+	}
+
+	h := w.Header()
+	addVary(h, "HX-Request", "HX-Target")
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,76 @@
+package htmxheaders_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+var cartTemplate = template.Must(template.New("page").Parse(
+	`<html>{{block "cart" .}}<ul id="cart">{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}` +
+		`{{block "summary" .}}<p id="cart-summary">{{len .}} items</p>{{end}}</html>`,
+))
+
+func renderCart(t *testing.T, renderer *hh.BlockRenderer, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/cart", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	require.NoError(t, renderer.Render(w, r, []string{"Milk", "Eggs"}))
+	return w
+}
+
+func TestBlockRenderer(t *testing.T) {
+	renderer := hh.NewBlockRenderer(cartTemplate, "")
+	renderer.Blocks = map[string]string{"cart-summary": "summary"}
+
+	page := `<html><ul id="cart"><li>Milk</li><li>Eggs</li></ul><p id="cart-summary">2 items</p></html>`
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"not htmx", map[string]string{"HX-Target": "cart"}, page},
+		{"block by id", map[string]string{"HX-Request": "true", "HX-Target": "cart"}, `<ul id="cart"><li>Milk</li><li>Eggs</li></ul>`},
+		{"mapped block", map[string]string{"HX-Request": "true", "HX-Target": "cart-summary"}, `<p id="cart-summary">2 items</p>`},
+		{"unknown target", map[string]string{"HX-Request": "true", "HX-Target": "checkout"}, page},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-Target": "cart"}, page},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true", "HX-Target": "cart"}, page},
+	}
+
+	for _, test := range tests {
+		w := renderCart(t, renderer, test.headers)
+		assert.Equal(t, test.expected, w.Body.String(), test.name)
+		assert.Equal(t, []string{"HX-Request", "HX-Target"}, w.Header().Values("Vary"), test.name)
+	}
+}
+
+func TestBlockRendererWithETag(t *testing.T) {
+	renderer := hh.NewBlockRenderer(cartTemplate, "page")
+	renderer.ETag = true
+	headers := map[string]string{"HX-Request": "true", "HX-Target": "cart"}
+
+	first := renderCart(t, renderer, headers)
+	require.NotEmpty(t, first.Header().Get("ETag"))
+
+	headers["If-None-Match"] = first.Header().Get("ETag")
+	second := renderCart(t, renderer, headers)
+	assert.Equal(t, http.StatusNotModified, second.Code)
+}
+
+func TestBlockRendererErrorWritesNothing(t *testing.T) {
+	tmpl := template.Must(template.New("page").Parse(`<p>{{.Missing}}</p>`))
+	w := httptest.NewRecorder()
+
+	err := hh.NewBlockRenderer(tmpl, "").Render(w, httptest.NewRequest("GET", "/", nil), 42)
+
+	assert.Error(t, err)
+	assert.Empty(t, w.Body.String())
+}
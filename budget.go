@@ -0,0 +1,281 @@
+package htmxheaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HeaderTooLargeError is returned when a decorator would make the response headers larger than a HeaderBudget allows.
+type HeaderTooLargeError struct {
+	Header string // the header being set
+	Size   int    // the size in bytes the response headers would have been
+	Limit  int    // the maximum size in bytes allowed
+}
+
+func (e *HeaderTooLargeError) Error() string {
+	return fmt.Sprintf("setting %s would make the response headers %d bytes, exceeding the budget of %d bytes", e.Header, e.Size, e.Limit)
+}
+
+// HeaderBudget is a Hook limiting the total size of the response headers set through SetResponseHeaders.
+//
+// Headers set with TriggerWithDetail can grow large with arbitrary details, and proxies commonly reject
+// responses whose headers exceed 4KB or 8KB without any error being seen in Go. A HeaderBudget turns this into
+// a *HeaderTooLargeError returned by SetResponseHeaders.
+//
+// If Fallback is true and the request is handled by the TriggerFallback middleware, trigger headers that exceed
+// the budget are removed and their events are delivered in the body instead, see TriggerFallback.
+//
+// Example usage:
+//
+//	hh.RegisterHook(hh.HeaderBudget{MaxBytes: 8 << 10, Fallback: true})
+//	http.ListenAndServe(":3000", hh.TriggerFallback(mux))
+type HeaderBudget struct {
+	MaxBytes int  // the maximum size of the response headers, as written on the wire
+	Fallback bool // deliver trigger events in the body when their header exceeds the budget
+}
+
+// BeforeApply returns a *HeaderTooLargeError if applying every header changed by the decorator would exceed
+// the budget, unless the budget is met once the trigger headers it sets are delivered in the body instead.
+func (b HeaderBudget) BeforeApply(w http.ResponseWriter, info DecoratorInfo) error {
+	after := applyChanges(w.Header(), info)
+	size := headerSize(after)
+	if size <= b.MaxBytes {
+		return nil
+	}
+
+	if b.Fallback && findTriggerQueue(w) != nil {
+		moved := triggerHeaders(info)
+		for _, name := range moved {
+			after.Del(name)
+		}
+		if len(moved) > 0 && headerSize(after) <= b.MaxBytes {
+			return nil
+		}
+	}
+	return &HeaderTooLargeError{Header: largestHeader(info), Size: size, Limit: b.MaxBytes}
+}
+
+// AfterApply moves the events of the trigger headers set by the decorator into the body when the headers
+// exceed the budget, if Fallback is enabled.
+func (b HeaderBudget) AfterApply(w http.ResponseWriter, info DecoratorInfo) {
+	if !b.Fallback || headerSize(w.Header()) <= b.MaxBytes {
+		return
+	}
+
+	queue := findTriggerQueue(w)
+	if queue == nil {
+		return
+	}
+
+	for _, name := range triggerHeaders(info) {
+		value := w.Header().Get(name)
+		events, err := DecodeTriggerHeader(value)
+		if err != nil {
+			continue
+		}
+		w.Header().Del(name)
+		queue.triggers = append(queue.triggers, queuedTrigger{header: name, value: value, events: events})
+	}
+}
+
+// OnError does nothing.
+func (b HeaderBudget) OnError(http.ResponseWriter, DecoratorInfo, error) {}
+
+// TriggerFallback returns a http.Handler allowing a HeaderBudget to deliver trigger events in the body
+// of the response when their header exceeds the budget.
+//
+// The events are appended to the body as an out of band fragment containing a script, which htmx swaps into
+// the end of the body, dispatching the events when it runs.
+//
+// Note:
+//
+//	Events without a Target are dispatched on the body, rather than the element that made the request, and all
+//	events are dispatched after the content has been swapped in, whatever TriggerDelay they were set with.
+//	Listeners must therefore be registered on the body or the document. The fallback only applies to
+//	successful text/html responses, and requires htmx to be allowed to run scripts (htmx.config.allowScriptTags).
+//	For any other response, such as a 422 or a JSON response, the trigger headers are restored when the status
+//	is written, so that the events are not lost, even though the headers then exceed the budget.
+func TriggerFallback(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queue := &triggerQueueWriter{statusWriter: newStatusWriter(w)}
+		queue.beforeWriteHeader = func(status int) {
+			if len(queue.triggers) == 0 {
+				return
+			}
+			if canAppendHTML(status, queue.Header()) {
+				queue.Header().Del("Content-Length")
+				return
+			}
+			queue.restore()
+		}
+
+		next.ServeHTTP(queue, r)
+		queue.finish()
+
+		if len(queue.triggers) == 0 || !canAppendHTML(queue.status, queue.header) {
+			return
+		}
+
+		var events []TriggerEvent
+		for _, trigger := range queue.triggers {
+			events = append(events, trigger.events...)
+		}
+		script, err := triggerScript(events)
+		if err != nil {
+			return
+		}
+		fragment := OOBFragment{Target: "body", Swap: SwapBeforeEnd, HTML: script}
+		_ = fragment.Render(queue)
+	})
+}
+
+// triggerQueueWriter holds the trigger headers moved out of the response by a HeaderBudget.
+type triggerQueueWriter struct {
+	*statusWriter
+	triggers []queuedTrigger
+}
+
+// queuedTrigger is a trigger header moved out of the response, along with its decoded events.
+type queuedTrigger struct {
+	header string
+	value  string
+	events []TriggerEvent
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (tw *triggerQueueWriter) Unwrap() http.ResponseWriter {
+	return tw.statusWriter
+}
+
+// restore sets the trigger headers moved out of the response again, for responses the events cannot be
+// appended to. Events set in the header since it was moved are kept, replacing moved events of the same name.
+func (tw *triggerQueueWriter) restore() {
+	for _, trigger := range tw.triggers {
+		existing := tw.Header().Get(trigger.header)
+		if existing == "" {
+			tw.Header().Set(trigger.header, trigger.value)
+			continue
+		}
+
+		later, err := DecodeTriggerHeader(existing)
+		if err != nil {
+			continue
+		}
+		var events []TriggerEvent
+		for _, event := range trigger.events {
+			if !slices.ContainsFunc(later, func(e TriggerEvent) bool { return e.Name == event.Name }) {
+				events = append(events, event)
+			}
+		}
+		if value, err := encodeTriggerEvents(append(events, later...)); err == nil {
+			tw.Header().Set(trigger.header, value)
+		}
+	}
+	tw.triggers = nil
+}
+
+// findTriggerQueue returns the triggerQueueWriter installed by TriggerFallback, if any.
+func findTriggerQueue(w http.ResponseWriter) *triggerQueueWriter {
+	for w != nil {
+		if queue, ok := w.(*triggerQueueWriter); ok {
+			return queue
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = unwrapper.Unwrap()
+	}
+	return nil
+}
+
+// triggerScript returns a script dispatching each event on its target, or the body, then removing itself.
+// json.Marshal escapes <, > and &, so the details cannot end the script element early.
+func triggerScript(events []TriggerEvent) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("<script>")
+	for _, event := range events {
+		name, err := json.Marshal(event.Name)
+		if err != nil {
+			return "", err
+		}
+		detail, err := json.Marshal(event.Detail)
+		if err != nil {
+			return "", err
+		}
+
+		elt := "document.body"
+		if event.Target != "" {
+			target, err := json.Marshal(event.Target)
+			if err != nil {
+				return "", err
+			}
+			elt = "htmx.find(" + string(target) + ")||document.body"
+		}
+		sb.WriteString("htmx.trigger(" + elt + "," + string(name) + "," + string(detail) + ");")
+	}
+	sb.WriteString("document.currentScript&&document.currentScript.remove();</script>")
+	return sb.String(), nil
+}
+
+func canAppendHTML(status int, h http.Header) bool {
+	if status < 200 || status >= 300 || status == http.StatusNoContent {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	return contentType == "" || strings.HasPrefix(contentType, "text/html")
+}
+
+// headerSize returns the size of the headers as written on the wire.
+func headerSize(h http.Header) int {
+	size := 0
+	for name, values := range h {
+		for _, value := range values {
+			size += len(name) + len(": ") + len(value) + len("\r\n")
+		}
+	}
+	return size
+}
+
+// applyChanges returns a copy of the headers with the changes made by the decorator applied.
+func applyChanges(h http.Header, info DecoratorInfo) http.Header {
+	after := h.Clone()
+	for name, values := range info.Headers {
+		after[name] = values
+	}
+	for _, name := range info.Removed {
+		delete(after, name)
+	}
+	return after
+}
+
+// triggerHeaders returns the trigger headers set by the decorator, in the order htmx dispatches them.
+func triggerHeaders(info DecoratorInfo) []string {
+	var names []string
+	for _, when := range []TriggerDelay{TriggerImmediately, TriggerAfterSettle, TriggerAfterSwap} {
+		for name := range info.Headers {
+			if strings.EqualFold(name, when.String()) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// largestHeader returns the name of the largest header set by the decorator.
+func largestHeader(info DecoratorInfo) string {
+	largest, size := info.Header, 0
+	for name, values := range info.Headers {
+		n := 0
+		for _, value := range values {
+			n += len(value)
+		}
+		if n > size || n == size && hxHeaderName(name) < largest {
+			largest, size = hxHeaderName(name), n
+		}
+	}
+	return largest
+}
@@ -0,0 +1,132 @@
+package htmxheaders_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func largeEvent() hh.TriggerEvent {
+	return hh.TriggerEvent{Name: "rowsLoaded", Detail: strings.Repeat("x", 200)}
+}
+
+func TestHeaderBudgetReturnsErrorWhenExceeded(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100})
+	w := httptest.NewRecorder()
+
+	err := builder.SetResponseHeaders(w, hh.Retarget("#rows"), hh.TriggerWithDetail(hh.TriggerImmediately, largeEvent()))
+
+	var tooLarge *hh.HeaderTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, "HX-Trigger", tooLarge.Header)
+	assert.Equal(t, 100, tooLarge.Limit)
+	assert.Greater(t, tooLarge.Size, 200)
+	assert.Equal(t, "#rows", w.Header().Get("HX-Retarget"))
+	assert.Empty(t, w.Header().Get("HX-Trigger"))
+}
+
+func TestHeaderBudgetAllowsHeadersWithinBudget(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100})
+	w := httptest.NewRecorder()
+
+	err := builder.SetResponseHeaders(w, hh.Trigger(hh.TriggerImmediately, "saved"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "saved", w.Header().Get("HX-Trigger"))
+}
+
+func TestHeaderBudgetFallbackWithoutMiddlewareReturnsError(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100, Fallback: true})
+
+	err := builder.SetResponseHeaders(httptest.NewRecorder(), hh.TriggerWithDetail(hh.TriggerImmediately, largeEvent()))
+
+	var tooLarge *hh.HeaderTooLargeError
+	assert.True(t, errors.As(err, &tooLarge))
+}
+
+func TestTriggerFallbackDeliversEventsInBody(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100, Fallback: true})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := builder.SetResponseHeaders(w,
+			hh.Trigger(hh.TriggerAfterSwap, "small"),
+			hh.TriggerWithDetail(hh.TriggerImmediately, hh.TriggerEvent{Name: "done", Detail: "</script>", Target: "#rows"}, largeEvent()),
+		)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Length", "11")
+		_, _ = w.Write([]byte("<p>rows</p>"))
+	})
+
+	w := httptest.NewRecorder()
+	hh.TriggerFallback(handler).ServeHTTP(w, httptest.NewRequest("GET", "/rows", nil))
+
+	expected := `<p>rows</p><div hx-swap-oob="beforeend:body"><script>` +
+		`htmx.trigger(htmx.find("#rows")||document.body,"done","\u003c/script\u003e");` +
+		`htmx.trigger(document.body,"rowsLoaded","` + strings.Repeat("x", 200) + `");` +
+		`document.currentScript&&document.currentScript.remove();</script></div>`
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expected, w.Body.String())
+	assert.Empty(t, w.Header().Get("HX-Trigger"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, "small", w.Header().Get("HX-Trigger-After-Swap"))
+}
+
+func TestTriggerFallbackRestoresHeadersOfNonHTMLResponses(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100, Fallback: true})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, builder.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately, largeEvent())))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	})
+
+	w := httptest.NewRecorder()
+	hh.TriggerFallback(handler).ServeHTTP(w, httptest.NewRequest("GET", "/rows", nil))
+
+	assert.Equal(t, "{}", w.Body.String())
+	events, err := hh.DecodeTriggerHeader(w.Header().Get("HX-Trigger"))
+	require.NoError(t, err)
+	assert.Equal(t, []hh.TriggerEvent{largeEvent()}, events)
+}
+
+func TestTriggerFallbackRestoresHeadersOfErrorResponses(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100, Fallback: true})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := builder.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately, largeEvent()))
+		require.NoError(t, err)
+		err = builder.SetResponseHeaders(w, hh.Trigger(hh.TriggerImmediately, "invalid"))
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte("<p>invalid</p>"))
+	})
+
+	w := httptest.NewRecorder()
+	hh.TriggerFallback(handler).ServeHTTP(w, httptest.NewRequest("POST", "/rows", nil))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "<p>invalid</p>", w.Body.String())
+	events, err := hh.DecodeTriggerHeader(w.Header().Get("HX-Trigger"))
+	require.NoError(t, err)
+	assert.Equal(t, []hh.TriggerEvent{largeEvent(), {Name: "invalid"}}, events)
+}
+
+func TestHeaderBudgetMeasuresEveryHeaderSetByADecorator(t *testing.T) {
+	builder := hh.NewBuilder(hh.HeaderBudget{MaxBytes: 100})
+	w := httptest.NewRecorder()
+	hxErr := hh.NewHXError(errors.New("invalid"), hh.Retarget("#x"), hh.TriggerWithDetail(hh.TriggerImmediately, largeEvent()))
+
+	err := builder.SetResponseHeaders(w, hxErr.Apply)
+
+	var tooLarge *hh.HeaderTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, "HX-Trigger", tooLarge.Header)
+	assert.Empty(t, w.Header().Get("HX-Trigger"))
+	assert.Empty(t, w.Header().Get("HX-Retarget"))
+}
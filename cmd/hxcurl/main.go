@@ -0,0 +1,327 @@
+// Command hxcurl sends a request to an htmx endpoint as htmx would, and prints the decoded HX response headers,
+// so that server behaviour can be debugged without a browser.
+//
+// Usage:
+//
+//	hxcurl [flags] url
+//
+// The request is sent with HX-Request: true and any of the HX request headers given by the flags. The response
+// status and HX response headers are printed, with trigger events, the HX-Location context and the HX-Reswap
+// modifiers decoded, followed by the response body.
+//
+// With --follow, the client-side navigation htmx does in response is followed: HX-Location is requested as an
+// htmx request targeting the context's target, while HX-Redirect and HX-Refresh are requested as full page loads.
+//
+// Example usage:
+//
+//	hxcurl --target cart --trigger add-button -d 'item=42' http://localhost:3000/cart
+//	hxcurl --follow --current-url http://localhost:3000/orders -X DELETE http://localhost:3000/orders/7
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// headerFlags collects repeated -H flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header %q is not of the form \"Name: value\"", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// options are the parsed command line flags.
+type options struct {
+	method         string
+	data           string
+	headers        headerFlags
+	target         string
+	trigger        string
+	triggerName    string
+	boosted        bool
+	currentURL     string
+	prompt         string
+	historyRestore bool
+	follow         bool
+	maxFollow      int
+	body           bool
+}
+
+// run runs hxcurl with the given arguments, returning the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet("hxcurl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hxcurl [flags] url")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.method, "X", "", "the request method, defaults to POST with -d and GET otherwise")
+	fs.StringVar(&opts.data, "d", "", "the form encoded request body")
+	fs.Var(&opts.headers, "H", "an additional request header, such as \"Cookie: session=1\", may be repeated")
+	fs.StringVar(&opts.target, "target", "", "the HX-Target header, the id of the target element")
+	fs.StringVar(&opts.trigger, "trigger", "", "the HX-Trigger header, the id of the triggered element")
+	fs.StringVar(&opts.triggerName, "trigger-name", "", "the HX-Trigger-Name header, the name of the triggered element")
+	fs.BoolVar(&opts.boosted, "boosted", false, "send HX-Boosted: true")
+	fs.StringVar(&opts.currentURL, "current-url", "", "the HX-Current-URL header, the URL of the page in the browser")
+	fs.StringVar(&opts.prompt, "prompt", "", "the HX-Prompt header, the response to an hx-prompt")
+	fs.BoolVar(&opts.historyRestore, "history-restore", false, "send HX-History-Restore-Request: true")
+	fs.BoolVar(&opts.follow, "follow", false, "follow HX-Location, HX-Redirect and HX-Refresh as htmx would")
+	fs.IntVar(&opts.maxFollow, "max-follow", 10, "the maximum number of responses followed with --follow")
+	fs.BoolVar(&opts.body, "body", true, "print the response body")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	c := &client{http: http.DefaultClient, out: stdout, opts: opts}
+	if err := c.do(fs.Arg(0)); err != nil {
+		fmt.Fprintln(stderr, "hxcurl:", err)
+		return 1
+	}
+	return 0
+}
+
+// client sends the requests and follows the responses.
+type client struct {
+	http *http.Client
+	out  io.Writer
+	opts options
+}
+
+// do sends the htmx request described by the options, and with --follow, the requests htmx would make in response.
+func (c *client) do(rawURL string) error {
+	req, err := c.htmxRequest(rawURL)
+	if err != nil {
+		return err
+	}
+
+	for follows := 0; ; follows++ {
+		resp, err := c.send(req)
+		if err != nil {
+			return err
+		}
+		if !c.opts.follow || resp.hx == nil {
+			return nil
+		}
+
+		next, err := c.followRequest(req, resp)
+		if err != nil || next == nil {
+			return err
+		}
+		if follows == c.opts.maxFollow {
+			return fmt.Errorf("stopped after following %d responses", follows)
+		}
+		fmt.Fprintln(c.out)
+		req = next
+	}
+}
+
+// htmxRequest creates the initial request, with the HX request headers given by the options.
+func (c *client) htmxRequest(rawURL string) (*http.Request, error) {
+	method := c.opts.method
+	if method == "" {
+		method = http.MethodGet
+		if c.opts.data != "" {
+			method = http.MethodPost
+		}
+	}
+
+	var body io.Reader
+	if c.opts.data != "" {
+		body = strings.NewReader(c.opts.data)
+	}
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.data != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	c.addHeaders(req)
+
+	req.Header.Set("HX-Request", "true")
+	for name, value := range map[string]string{
+		"HX-Target":       c.opts.target,
+		"HX-Trigger":      c.opts.trigger,
+		"HX-Trigger-Name": c.opts.triggerName,
+		"HX-Current-URL":  c.opts.currentURL,
+		"HX-Prompt":       c.opts.prompt,
+	} {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if c.opts.boosted {
+		req.Header.Set("HX-Boosted", "true")
+	}
+	if c.opts.historyRestore {
+		req.Header.Set("HX-History-Restore-Request", "true")
+	}
+	return req, nil
+}
+
+// addHeaders adds the headers given with -H to the request.
+func (c *client) addHeaders(req *http.Request) {
+	for _, header := range c.opts.headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+}
+
+// response is a response that has been printed.
+type response struct {
+	status int
+	hx     *hh.Response // the decoded HX response headers, nil if the response has none
+}
+
+// send sends the request and prints the response.
+func (c *client) send(req *http.Request) (response, error) {
+	fmt.Fprintf(c.out, "> %s %s\n", req.Method, req.URL)
+	for _, name := range sortedHeaderNames(req.Header, true) {
+		fmt.Fprintf(c.out, "> %s: %s\n", displayHeaderName(name), req.Header.Get(name))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+
+	fmt.Fprintf(c.out, "< %s %s\n", resp.Proto, resp.Status)
+	result := response{status: resp.StatusCode}
+	if len(sortedHeaderNames(resp.Header, false)) > 0 {
+		hx, err := hh.ParseResponse(resp.Header)
+		printResponse(c.out, hx)
+		if err != nil {
+			fmt.Fprintf(c.out, "! %s\n", strings.ReplaceAll(err.Error(), "\n", "\n! "))
+		}
+		result.hx = &hx
+	}
+
+	if !c.opts.body || req.Method == http.MethodHead {
+		return result, nil
+	}
+	fmt.Fprintln(c.out)
+	if _, err := io.Copy(c.out, resp.Body); err != nil {
+		return response{}, err
+	}
+	return result, nil
+}
+
+// followRequest returns the request htmx makes in response to the HX response headers, or nil if there is none.
+// HX-Redirect and HX-Refresh cause a full page load, so the requests made for them are not htmx requests.
+func (c *client) followRequest(prev *http.Request, resp response) (*http.Request, error) {
+	switch {
+	case resp.hx.Redirect != "":
+		return c.pageRequest(prev, resp.hx.Redirect)
+
+	case resp.hx.Refresh:
+		current := prev.Header.Get("HX-Current-URL")
+		if current == "" {
+			fmt.Fprintln(c.out, "\nHX-Refresh not followed: the current URL is unknown, set it with --current-url")
+			return nil, nil
+		}
+		return c.pageRequest(prev, current)
+
+	case resp.hx.Location != nil:
+		return c.locationRequest(prev, resp.hx.Location)
+	}
+	return nil, nil
+}
+
+// pageRequest returns a full page GET request for the URL, keeping the headers given with -H.
+func (c *client) pageRequest(prev *http.Request, rawURL string) (*http.Request, error) {
+	u, err := prev.URL.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.addHeaders(req)
+	return req, nil
+}
+
+// locationRequest returns the htmx request made for HX-Location: a GET of the path, targeting the context's target,
+// or the body if it has none, with the values of the context as the query.
+// https://htmx.org/headers/hx-location/
+func (c *client) locationRequest(prev *http.Request, location *hh.LocationContextWithPath) (*http.Request, error) {
+	req, err := c.pageRequest(prev, location.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if location.Values != "" {
+		values, err := locationValues(location.Values)
+		if err != nil {
+			return nil, err
+		}
+		query := req.URL.Query()
+		for name, vs := range values {
+			query[name] = append(query[name], vs...)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+
+	req.Header.Set("HX-Request", "true")
+	if current := prev.Header.Get("HX-Current-URL"); current != "" {
+		req.Header.Set("HX-Current-URL", current)
+	}
+	if target, ok := strings.CutPrefix(location.Target, "#"); ok {
+		req.Header.Set("HX-Target", target)
+	}
+	return req, nil
+}
+
+// locationValues decodes the values of a HX-Location context, given as a JSON object or a query string.
+func locationValues(values string) (url.Values, error) {
+	if !strings.HasPrefix(strings.TrimSpace(values), "{") {
+		return url.ParseQuery(values)
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal([]byte(values), &object); err != nil {
+		return nil, fmt.Errorf("error decoding HX-Location values: %w", err)
+	}
+	result := url.Values{}
+	for name, value := range object {
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				result.Add(name, fmt.Sprint(item))
+			}
+		default:
+			result.Add(name, fmt.Sprint(v))
+		}
+	}
+	return result, nil
+}
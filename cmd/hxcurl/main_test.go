@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// newTestServer starts a server for the tests. Its handlers run on the server's goroutines, so they report
+// errors with t.Error rather than require, which must only be called from the test goroutine.
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cart", func(w http.ResponseWriter, r *http.Request) {
+		req := hh.ParseRequest(r)
+		err := hh.SetResponseHeaders(w,
+			hh.ReswapSpec(hh.SwapSpec{Swap: hh.SwapOuterHTML, SwapDelay: 500 * time.Millisecond, Scroll: "top"}),
+			hh.Retarget("#"+req.Target),
+			hh.TriggerWithDetail(hh.TriggerAfterSwap, hh.TriggerEvent{Name: "itemAdded", Detail: map[string]int{"item": 42}}),
+		)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = w.Write([]byte("<div>" + r.FormValue("item") + " " + req.Prompt + "</div>"))
+	})
+	mux.HandleFunc("/orders/7", func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w, hh.LocationWithContext("/orders", hh.LocationContext{Target: "#orders", Values: `{"page":2}`}))
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("orders page " + r.URL.Query().Get("page") + " into " + hh.ParseRequest(r).Target))
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if err := hh.SetResponseHeaders(w, hh.Redirect("/login")); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("login, htmx: " + r.Header.Get("HX-Request")))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		if err := hh.SetResponseHeaders(w, hh.Location("/loop")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunPrintsDecodedResponse(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"--target", "cart", "--trigger", "add", "--prompt", "yes", "-d", "item=42", server.URL + "/cart"}, &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "> POST "+server.URL+"/cart\n")
+	assert.Contains(t, out, "> HX-Request: true\n")
+	assert.Contains(t, out, "> HX-Target: cart\n")
+	assert.Contains(t, out, "> HX-Trigger: add\n")
+	assert.Contains(t, out, "< HTTP/1.1 200 OK\n")
+	assert.Contains(t, out, "< HX-Reswap: outerHTML swap:500ms scroll:top\n<   swap: outerHTML\n<   swap delay: 500ms\n<   scroll: top\n")
+	assert.Contains(t, out, "< HX-Retarget: #cart\n")
+	assert.Contains(t, out, "< HX-Trigger-After-Swap:\n<   itemAdded {\"item\":42}\n")
+	assert.Contains(t, out, "\n<div>42 yes</div>")
+}
+
+func TestRunFollowsLocation(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"--follow", "--body=false", "-X", "DELETE", server.URL + "/orders/7"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "< HX-Location: /orders\n<   target: #orders\n<   values: {\"page\":2}\n")
+	assert.Contains(t, out, "> GET "+server.URL+"/orders?page=2\n")
+	assert.Contains(t, out, "> HX-Target: orders\n")
+	assert.NotContains(t, out, "orders page")
+
+	stdout.Reset()
+	code = run([]string{"--follow", server.URL + "/orders/7"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "orders page 2 into orders")
+}
+
+func TestRunFollowsRedirectAsFullPageLoad(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"--follow", server.URL + "/logout"}, &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "< HX-Redirect: /login\n")
+	assert.Contains(t, stdout.String(), "> GET "+server.URL+"/login\n< HTTP/1.1 200 OK\n")
+	assert.True(t, strings.HasSuffix(stdout.String(), "login, htmx: "), "the redirect is not an htmx request")
+}
+
+func TestRunWithoutFollowDoesNotFollow(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{server.URL + "/logout"}, &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "< HX-Redirect: /login\n")
+	assert.NotContains(t, stdout.String(), "> GET "+server.URL+"/login")
+}
+
+func TestRunStopsAfterMaxFollow(t *testing.T) {
+	server := newTestServer(t)
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"--follow", "--max-follow", "3", server.URL + "/loop"}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "stopped after following 3 responses")
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "usage: hxcurl")
+
+	stderr.Reset()
+	assert.Equal(t, 2, run([]string{"-H", "no colon", "http://localhost"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "is not of the form")
+}
+
+func TestLocationValues(t *testing.T) {
+	values, err := locationValues(`{"page":2,"tags":["a","b"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "page=2&tags=a&tags=b", values.Encode())
+
+	values, err = locationValues("page=3")
+	require.NoError(t, err)
+	assert.Equal(t, "3", values.Get("page"))
+
+	_, err = locationValues("{")
+	assert.Error(t, err)
+}
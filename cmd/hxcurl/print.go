@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// sortedHeaderNames returns the sorted names of the headers, only the HX headers unless all is set.
+func sortedHeaderNames(h http.Header, all bool) []string {
+	var names []string
+	for name := range h {
+		if all || strings.HasPrefix(strings.ToUpper(name), "HX-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// displayHeaderName returns the header name with the HX prefix in upper case, as htmx documents it,
+// rather than as canonicalized by http.Header.
+func displayHeaderName(name string) string {
+	if strings.HasPrefix(strings.ToUpper(name), "HX-") {
+		return "HX-" + name[3:]
+	}
+	return name
+}
+
+// printResponse prints the decoded HX response headers, one header per line followed by its decoded parts.
+func printResponse(w io.Writer, resp hh.Response) {
+	if resp.Location != nil {
+		fmt.Fprintf(w, "< HX-Location: %s\n", resp.Location.Path)
+		printFields(w, [][2]string{
+			{"source", resp.Location.Source},
+			{"event", resp.Location.Event},
+			{"handler", resp.Location.Handler},
+			{"target", resp.Location.Target},
+			{"swap", locationSwap(resp.Location)},
+			{"values", resp.Location.Values},
+			{"select", resp.Location.Select},
+		})
+	}
+	printHeader(w, "HX-Push-Url", resp.PushURL)
+	printHeader(w, "HX-Redirect", resp.Redirect)
+	if resp.Refresh {
+		printHeader(w, "HX-Refresh", "true")
+	}
+	printHeader(w, "HX-Replace-Url", resp.ReplaceURL)
+
+	if spec := resp.Reswap; spec != nil {
+		fmt.Fprintf(w, "< HX-Reswap: %s\n", spec)
+		fields := [][2]string{{"swap", spec.Swap.String()}}
+		if spec.Transition {
+			fields = append(fields, [2]string{"transition", "true"})
+		}
+		if spec.SwapDelay > 0 {
+			fields = append(fields, [2]string{"swap delay", spec.SwapDelay.String()})
+		}
+		if spec.SettleDelay > 0 {
+			fields = append(fields, [2]string{"settle delay", spec.SettleDelay.String()})
+		}
+		if spec.IgnoreTitle {
+			fields = append(fields, [2]string{"ignore title", "true"})
+		}
+		fields = append(fields, [2]string{"scroll", spec.Scroll}, [2]string{"show", spec.Show})
+		if spec.FocusScroll != nil {
+			fields = append(fields, [2]string{"focus scroll", fmt.Sprint(*spec.FocusScroll)})
+		}
+		printFields(w, fields)
+	}
+
+	printHeader(w, "HX-Retarget", resp.Retarget)
+	printHeader(w, "HX-Reselect", resp.Reselect)
+
+	for _, trigger := range []struct {
+		header string
+		events []hh.TriggerEvent
+	}{
+		{hh.TriggerImmediately.String(), resp.Trigger},
+		{hh.TriggerAfterSettle.String(), resp.TriggerAfterSettle},
+		{hh.TriggerAfterSwap.String(), resp.TriggerAfterSwap},
+	} {
+		if len(trigger.events) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "< %s:\n", trigger.header)
+		for _, event := range trigger.events {
+			fmt.Fprintf(w, "<   %s", event.Name)
+			if event.Target != "" {
+				fmt.Fprintf(w, " on %s", event.Target)
+			}
+			if event.Detail != nil {
+				detail, err := json.Marshal(event.Detail)
+				if err != nil {
+					detail = []byte(fmt.Sprint(event.Detail))
+				}
+				fmt.Fprintf(w, " %s", detail)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func printHeader(w io.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "< %s: %s\n", name, value)
+	}
+}
+
+// printFields prints the fields that are set, indented below their header.
+func printFields(w io.Writer, fields [][2]string) {
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(w, "<   %s: %s\n", field[0], field[1])
+		}
+	}
+}
+
+// locationSwap returns the swap of a HX-Location context, or "" if the context does not set one.
+func locationSwap(location *hh.LocationContextWithPath) string {
+	if location.Swap == hh.SwapInnerHTML {
+		return ""
+	}
+	return location.Swap.String()
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// importPath is the import path of the htmxheaders package.
+const importPath = "github.com/thisisthemurph/htmxheaders"
+
+// Diagnostic is a problem found by a check.
+type Diagnostic struct {
+	Pos     token.Position
+	Check   string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Check)
+}
+
+// conflicts lists decorators that contradict each other when applied to the same response.
+var conflicts = [][2]string{
+	{"Redirect", "Location"},
+	{"Redirect", "LocationWithContext"},
+	{"Redirect", "Refresh"},
+	{"Location", "Refresh"},
+	{"LocationWithContext", "Refresh"},
+	{"Location", "LocationWithContext"},
+	{"PushURL", "PreventPushURL"},
+	{"ReplaceURL", "PreventReplaceURL"},
+	{"Refresh", "PreventRefresh"},
+}
+
+// singleValued lists decorators setting a header that can only hold one value, so applying them
+// twice in the same call discards the first.
+var singleValued = map[string]bool{
+	"Location":            true,
+	"LocationWithContext": true,
+	"PushURL":             true,
+	"Redirect":            true,
+	"ReplaceURL":          true,
+	"Reselect":            true,
+	"Reswap":              true,
+	"ReswapSpec":          true,
+	"Retarget":            true,
+}
+
+// linter checks a single file, using the type information of its package to resolve the identifiers it uses,
+// so that the package is recognised however it is imported, and shadowed names are not mistaken for it.
+type linter struct {
+	fset  *token.FileSet
+	info  *types.Info
+	diags []Diagnostic
+}
+
+// lintPackage type-checks the files of a package and runs every check on them. Type errors, such as those caused
+// by imports that cannot be found, are ignored, so that the checks still run on the identifiers that resolve.
+func lintPackage(fset *token.FileSet, imp types.Importer, files []*ast.File) []Diagnostic {
+	if !slices.ContainsFunc(files, importsPackage) {
+		return nil
+	}
+
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	_, _ = conf.Check(files[0].Name.Name, fset, files, info)
+
+	var diags []Diagnostic
+	for _, file := range files {
+		if importsPackage(file) {
+			diags = append(diags, lintFile(fset, info, file)...)
+		}
+	}
+	return diags
+}
+
+// lintFile runs every check on the file.
+func lintFile(fset *token.FileSet, info *types.Info, file *ast.File) []Diagnostic {
+	l := &linter{fset: fset, info: info}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ExprStmt:
+			l.checkDiscarded(n.X)
+		case *ast.AssignStmt:
+			l.checkIgnored(n)
+		case *ast.CallExpr:
+			l.checkConflicts(n)
+			l.checkTriggerNames(n)
+		case *ast.CompositeLit:
+			l.checkTriggerEvent(n)
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				l.checkWriteOrder(n.Body.List, map[string]token.Pos{})
+			}
+		case *ast.FuncLit:
+			l.checkWriteOrder(n.Body.List, map[string]token.Pos{})
+		}
+		return true
+	})
+	return l.diags
+}
+
+// importsPackage reports whether the file imports the htmxheaders package.
+func importsPackage(file *ast.File) bool {
+	return slices.ContainsFunc(file.Imports, func(spec *ast.ImportSpec) bool {
+		path, err := strconv.Unquote(spec.Path.Value)
+		return err == nil && path == importPath
+	})
+}
+
+func (l *linter) report(pos token.Pos, check, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{Pos: l.fset.Position(pos), Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// object returns the object an identifier or selector expression refers to, or nil if it is not resolved.
+func (l *linter) object(expr ast.Expr) types.Object {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return l.info.Uses[e]
+	case *ast.SelectorExpr:
+		return l.info.Uses[e.Sel]
+	case *ast.ParenExpr:
+		return l.object(e.X)
+	}
+	return nil
+}
+
+// isPackage reports whether the expression is the name of an imported package with the given path.
+func (l *linter) isPackage(expr ast.Expr, path string) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkg, ok := l.info.Uses[ident].(*types.PkgName)
+	return ok && pkg.Imported().Path() == path
+}
+
+// funcName returns the name of the package function called, or "" if the call is not to the package.
+func (l *linter) funcName(call *ast.CallExpr) string {
+	if fn, ok := l.object(call.Fun).(*types.Func); ok {
+		if fn.Pkg() == nil || fn.Pkg().Path() != importPath || fn.Type().(*types.Signature).Recv() != nil {
+			return ""
+		}
+		return fn.Name()
+	}
+
+	// The functions of the package are not resolved if it could not be imported, but its name still is.
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok && l.isPackage(sel.X, importPath) {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+// methodName returns the name of the package method called, such as "Policies.Apply", or "" if the call is not
+// to a method of a type of the package.
+func (l *linter) methodName(call *ast.CallExpr) string {
+	fn, ok := l.object(call.Fun).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != importPath {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return ""
+	}
+	return named.Obj().Name() + "." + fn.Name()
+}
+
+// returnsError reports whether the call is to a package function whose error must be checked.
+func (l *linter) returnsError(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	name := l.funcName(call)
+	return name, name == "SetResponseHeaders" || name == "RemoveHXHeaders"
+}
+
+// checkDiscarded reports calls whose error result is discarded by using them as a statement.
+func (l *linter) checkDiscarded(expr ast.Expr) {
+	if name, ok := l.returnsError(expr); ok {
+		l.report(expr.Pos(), "errcheck", "error returned by %s is not checked", name)
+	}
+}
+
+// checkIgnored reports calls whose error result is assigned to the blank identifier.
+func (l *linter) checkIgnored(assign *ast.AssignStmt) {
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return
+	}
+	if ident, ok := assign.Lhs[0].(*ast.Ident); !ok || ident.Name != "_" {
+		return
+	}
+	if name, ok := l.returnsError(assign.Rhs[0]); ok {
+		l.report(assign.Pos(), "errcheck", "error returned by %s is assigned to _", name)
+	}
+}
+
+// checkConflicts reports decorators contradicting each other in the same call to SetResponseHeaders.
+func (l *linter) checkConflicts(call *ast.CallExpr) {
+	if l.funcName(call) != "SetResponseHeaders" || len(call.Args) < 2 {
+		return
+	}
+
+	seen := map[string]token.Pos{}
+	for _, arg := range call.Args[1:] {
+		decorator, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		name := l.funcName(decorator)
+		if name == "" {
+			continue
+		}
+
+		if _, ok := seen[name]; ok && singleValued[name] {
+			l.report(decorator.Pos(), "conflict", "%s is applied more than once, only the last value is sent", name)
+		}
+		for _, pair := range conflicts {
+			for i, other := range []string{pair[1], pair[0]} {
+				if name == pair[i] {
+					if _, ok := seen[other]; ok {
+						l.report(decorator.Pos(), "conflict", "%s conflicts with %s applied in the same call", name, other)
+					}
+				}
+			}
+		}
+		seen[name] = decorator.Pos()
+	}
+}
+
+// checkTriggerNames reports string literal event names passed to Trigger that htmx cannot dispatch.
+func (l *linter) checkTriggerNames(call *ast.CallExpr) {
+	if l.funcName(call) != "Trigger" || len(call.Args) < 2 || call.Ellipsis.IsValid() {
+		return
+	}
+	for _, arg := range call.Args[1:] {
+		value, ok := stringLiteral(arg)
+		if !ok {
+			continue
+		}
+		// Trigger accepts a comma separated list of names in a single argument.
+		for _, name := range strings.Split(value, ",") {
+			if problem := eventNameProblem(strings.TrimSpace(name)); problem != "" {
+				l.report(arg.Pos(), "eventname", "event name %q %s", strings.TrimSpace(name), problem)
+			}
+		}
+	}
+}
+
+// checkTriggerEvent reports string literal names of TriggerEvent literals that htmx cannot dispatch.
+func (l *linter) checkTriggerEvent(lit *ast.CompositeLit) {
+	if !l.isPackageType(lit.Type, "TriggerEvent") {
+		return
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "Name" {
+			continue
+		}
+		name, ok := stringLiteral(kv.Value)
+		if !ok {
+			continue
+		}
+		problem := eventNameProblem(name)
+		if problem == "" && strings.Contains(name, ",") {
+			problem = "contains a comma"
+		}
+		if problem != "" {
+			l.report(kv.Value.Pos(), "eventname", "event name %q %s", name, problem)
+		}
+	}
+}
+
+func (l *linter) isPackageType(expr ast.Expr, name string) bool {
+	if obj, ok := l.object(expr).(*types.TypeName); ok {
+		return obj.Pkg() != nil && obj.Pkg().Path() == importPath && obj.Name() == name
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	return ok && l.isPackage(sel.X, importPath) && sel.Sel.Name == name
+}
+
+// isDecorator reports whether the expression is a DecoratorFunction of the package.
+func (l *linter) isDecorator(expr ast.Expr) bool {
+	named, ok := l.info.TypeOf(expr).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == importPath && obj.Name() == "DecoratorFunction"
+}
+
+// headerSetter returns the name of the package function or method the call sets response headers with, and the
+// writer it sets them on, or "" if the call does not set response headers. Decorators called directly, as in
+// hh.Retarget("#main")(w), are named after the function returning them.
+func (l *linter) headerSetter(call *ast.CallExpr) (name string, w ast.Expr) {
+	if len(call.Args) == 0 {
+		return "", nil
+	}
+	switch name := l.funcName(call); name {
+	case "SetResponseHeaders", "RemoveHXHeaders", "Marshal", "ApplyPolicy":
+		return name, call.Args[0]
+	}
+	switch name := l.methodName(call); name {
+	case "Builder.SetResponseHeaders", "Policies.Apply", "HXError.Apply":
+		return name, call.Args[0]
+	}
+	if l.isDecorator(call.Fun) {
+		if inner, ok := call.Fun.(*ast.CallExpr); ok && l.funcName(inner) != "" {
+			return l.funcName(inner), call.Args[0]
+		}
+		if name := exprName(call.Fun); name != "" {
+			return name, call.Args[0]
+		}
+		return "DecoratorFunction", call.Args[0]
+	}
+	return "", nil
+}
+
+// eventNameProblem describes why the event name cannot be dispatched by htmx, or returns "" if it can.
+func eventNameProblem(name string) string {
+	if name == "" {
+		return "is empty"
+	}
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			return "contains whitespace"
+		case unicode.IsControl(r):
+			return "contains a control character"
+		case strings.ContainsRune(`"'{}[]`, r):
+			return fmt.Sprintf("contains the illegal character %q", r)
+		}
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// checkWriteOrder reports calls setting response headers, such as SetResponseHeaders, Marshal or a decorator, on a writer that has already been written to in the
+// statements before it, as the headers have then already been sent. written maps the names of writers to the
+// position they were first written at. Nested blocks are checked with a copy, and their writes only carry over
+// to the following statements if the block does not end by returning.
+func (l *linter) checkWriteOrder(stmts []ast.Stmt, written map[string]token.Pos) {
+	for _, stmt := range stmts {
+		for _, block := range nestedBlocks(stmt) {
+			inner := copyWritten(written)
+			l.checkWriteOrder(block, inner)
+			if !l.terminates(block) {
+				for name, pos := range inner {
+					if _, ok := written[name]; !ok {
+						written[name] = pos
+					}
+				}
+			}
+		}
+		if _, ok := stmt.(*ast.BlockStmt); ok {
+			continue
+		}
+
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit, *ast.BlockStmt:
+				return false
+			case *ast.CallExpr:
+				if setter, arg := l.headerSetter(n); setter != "" {
+					if name := exprName(arg); name != "" {
+						if pos, ok := written[name]; ok {
+							l.report(n.Pos(), "writeorder", "%s is called after %s was written to at line %d, the headers will not be sent",
+								setter, name, l.fset.Position(pos).Line)
+						}
+					}
+				}
+				if name := l.writtenWriter(n); name != "" {
+					if _, ok := written[name]; !ok {
+						written[name] = n.Pos()
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// nestedBlocks returns the statement lists nested directly in the statement.
+func nestedBlocks(stmt ast.Stmt) [][]ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		return [][]ast.Stmt{s.List}
+	case *ast.IfStmt:
+		blocks := [][]ast.Stmt{s.Body.List}
+		if s.Else != nil {
+			blocks = append(blocks, nestedBlocks(s.Else)...)
+		}
+		return blocks
+	case *ast.ForStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.RangeStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.SwitchStmt:
+		return caseBodies(s.Body)
+	case *ast.TypeSwitchStmt:
+		return caseBodies(s.Body)
+	case *ast.SelectStmt:
+		return caseBodies(s.Body)
+	}
+	return nil
+}
+
+func caseBodies(body *ast.BlockStmt) [][]ast.Stmt {
+	var blocks [][]ast.Stmt
+	for _, stmt := range body.List {
+		switch c := stmt.(type) {
+		case *ast.CaseClause:
+			blocks = append(blocks, c.Body)
+		case *ast.CommClause:
+			blocks = append(blocks, c.Body)
+		}
+	}
+	return blocks
+}
+
+func (l *linter) terminates(stmts []ast.Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	switch s := stmts[len(stmts)-1].(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok == token.CONTINUE || s.Tok == token.BREAK || s.Tok == token.GOTO
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		builtin, ok := l.object(call.Fun).(*types.Builtin)
+		return ok && builtin.Name() == "panic"
+	}
+	return false
+}
+
+func copyWritten(written map[string]token.Pos) map[string]token.Pos {
+	c := make(map[string]token.Pos, len(written))
+	for name, pos := range written {
+		c[name] = pos
+	}
+	return c
+}
+
+// writtenWriter returns the name of the writer a call writes the body or status to, such as w in
+// w.Write(b), fmt.Fprintf(w, ...) or tmpl.Execute(w, data), or "" if the call writes to no writer.
+func (l *linter) writtenWriter(call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	switch sel.Sel.Name {
+	case "Write", "WriteHeader", "WriteString":
+		// w.Write(b), and io.WriteString(w, s)
+		if l.isPackage(sel.X, "io") && sel.Sel.Name == "WriteString" {
+			return firstArgName(call)
+		}
+		return exprName(sel.X)
+	case "Fprint", "Fprintf", "Fprintln":
+		if l.isPackage(sel.X, "fmt") {
+			return firstArgName(call)
+		}
+	case "Execute", "ExecuteTemplate", "Render":
+		return firstArgName(call)
+	case "Error":
+		// http.Error(w, msg, code)
+		if l.isPackage(sel.X, "net/http") {
+			return firstArgName(call)
+		}
+	}
+	return ""
+}
+
+func firstArgName(call *ast.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	return exprName(call.Args[0])
+}
+
+// exprName returns the name of an identifier or selector expression, such as "w" or "s.w".
+func exprName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		if x := exprName(e.X); x != "" {
+			return x + "." + e.Sel.Name
+		}
+	case *ast.ParenExpr:
+		return exprName(e.X)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLintPackage checks the diagnostics reported for the files in testdata against the `// want "pattern"`
+// comments on the lines they are expected on.
+func TestLintPackage(t *testing.T) {
+	filenames, err := goFiles("testdata")
+	require.NoError(t, err)
+	require.NotEmpty(t, filenames)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	want := map[string]*regexp.Regexp{}
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		require.NoError(t, err)
+		files = append(files, file)
+
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				pattern, ok := strings.CutPrefix(c.Text, "// want ")
+				if !ok {
+					continue
+				}
+				pattern, err := strconv.Unquote(pattern)
+				require.NoError(t, err)
+				pos := fset.Position(c.Pos())
+				want[fmt.Sprintf("%s:%d", pos.Filename, pos.Line)] = regexp.MustCompile(regexp.QuoteMeta(pattern))
+			}
+		}
+	}
+
+	for _, diag := range lintPackage(fset, newImporter(fset), files) {
+		line := fmt.Sprintf("%s:%d", diag.Pos.Filename, diag.Pos.Line)
+		re, ok := want[line]
+		if !assert.True(t, ok, "unexpected diagnostic: %s", diag) {
+			continue
+		}
+		assert.Regexp(t, re, diag.Message)
+		delete(want, line)
+	}
+	for line, re := range want {
+		t.Errorf("%s: no diagnostic matching %q", line, re)
+	}
+}
+
+func TestEventNameProblem(t *testing.T) {
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{"showAlert", ""},
+		{"item:saved", ""},
+		{"", "is empty"},
+		{"item saved", "contains whitespace"},
+		{"tab\there", "contains whitespace"},
+		{"a\x00b", "contains a control character"},
+		{`{"a":1}`, `contains the illegal character '{'`},
+		{"it's", `contains the illegal character '\''`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, eventNameProblem(tc.name))
+		})
+	}
+}
+
+func TestGoFiles(t *testing.T) {
+	files, err := goFiles("./...")
+	require.NoError(t, err)
+	assert.Contains(t, files, "lint.go")
+	assert.NotContains(t, files, "testdata/misuse.go")
+
+	files, err = goFiles("testdata/misuse.go")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"testdata/misuse.go"}, files)
+
+	_, err = goFiles("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+// Command hxlint reports common misuse of the htmxheaders package:
+//
+//   - errors returned by SetResponseHeaders and RemoveHXHeaders that are discarded or assigned to _
+//   - SetResponseHeaders, Marshal, ApplyPolicy or a decorator called on a writer after its body or status has been
+//     written, when the headers are no longer sent
+//   - decorators contradicting each other in the same call, such as Redirect with Location
+//   - Trigger and TriggerEvent names that htmx cannot dispatch, such as names containing whitespace or quotes
+//
+// Usage:
+//
+//	hxlint [path ...]
+//
+// Each path is a Go file or a directory, with a trailing /... checking its subdirectories too. With no paths,
+// the current directory and its subdirectories are checked. Problems are printed one per line, and hxlint exits
+// with status 1 if any were found.
+//
+// The files of each package are type-checked, with their imports loaded from source, so that the package is
+// recognised however it is imported and local names shadowing it are ignored.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: hxlint [path ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	diags, err := lintPaths(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hxlint:", err)
+		os.Exit(2)
+	}
+	for _, diag := range diags {
+		fmt.Println(diag)
+	}
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
+
+// lintPaths checks the Go files at the given paths, returning the problems found sorted by position.
+// The files of each directory are type-checked together, with a separate package for external tests.
+func lintPaths(paths []string) ([]Diagnostic, error) {
+	var files []string
+	for _, path := range paths {
+		found, err := goFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	fset := token.NewFileSet()
+	var keys []string
+	packages := map[string][]*ast.File{}
+	for _, filename := range files {
+		file, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		key := filepath.Dir(filename) + " " + file.Name.Name
+		if _, ok := packages[key]; !ok {
+			keys = append(keys, key)
+		}
+		packages[key] = append(packages[key], file)
+	}
+
+	imp := newImporter(fset)
+	var diags []Diagnostic
+	for _, key := range keys {
+		diags = append(diags, lintPackage(fset, imp, packages[key])...)
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Pos, diags[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diags, nil
+}
+
+// newImporter returns an importer type-checking packages from source, which does not require them to have been built.
+func newImporter(fset *token.FileSet) types.Importer {
+	return importer.ForCompiler(fset, "source", nil)
+}
+
+// goFiles returns the Go files at the path. A directory path ending in /... includes its subdirectories,
+// except for vendor, testdata and hidden directories.
+func goFiles(path string) ([]string, error) {
+	recursive := false
+	if rest, ok := strings.CutSuffix(path, "/..."); ok {
+		path, recursive = rest, true
+		if path == "" {
+			path = "."
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == path {
+				return nil
+			}
+			base := d.Name()
+			if !recursive || base == "vendor" || base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") {
+			files = append(files, name)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,51 @@
+package testdata
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	htmx "github.com/thisisthemurph/htmxheaders"
+)
+
+type headers struct{}
+
+func (headers) SetResponseHeaders(w http.ResponseWriter) error { return nil }
+
+func aliased(w http.ResponseWriter) {
+	_ = htmx.SetResponseHeaders(w, htmx.Refresh()) // want "error returned by SetResponseHeaders is assigned to _"
+}
+
+func shadowed(w http.ResponseWriter) {
+	htmx := headers{}
+	_ = htmx.SetResponseHeaders(w)
+}
+
+type orderResponse struct {
+	Retarget string `hx:"retarget"`
+}
+
+func lateMarshal(w http.ResponseWriter) error {
+	io.WriteString(w, "<p>saved</p>")
+	return htmx.Marshal(w, orderResponse{Retarget: "#orders"}) // want "Marshal is called after w was written to at line 29"
+}
+
+func latePolicy(w http.ResponseWriter, policies *htmx.Policies) error {
+	if err := json.NewEncoder(w).Encode("saved"); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	if err := htmx.ApplyPolicy(w, "saved"); err != nil { // want "ApplyPolicy is called after w was written to at line 37"
+		return err
+	}
+	return policies.Apply(w, "saved") // want "Policies.Apply is called after w was written to at line 37"
+}
+
+func lateDecorator(w http.ResponseWriter) error {
+	w.Write([]byte("<p>saved</p>"))
+	if err := htmx.Retarget("#main")(w); err != nil { // want "Retarget is called after w was written to at line 45"
+		return err
+	}
+	reswap := htmx.Reswap(htmx.SwapOuterHTML)
+	return reswap(w) // want "reswap is called after w was written to at line 45"
+}
@@ -0,0 +1,58 @@
+package testdata
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+var tmpl = template.Must(template.New("t").Parse(`{{.}}`))
+
+func ignored(w http.ResponseWriter) {
+	_ = hh.SetResponseHeaders(w, hh.Retarget("#error")) // want "error returned by SetResponseHeaders is assigned to _"
+	hh.RemoveHXHeaders(w)                               // want "error returned by RemoveHXHeaders is not checked"
+
+	if err := hh.SetResponseHeaders(w, hh.Refresh()); err != nil {
+		return
+	}
+}
+
+func writeOrder(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("early") {
+		fmt.Fprint(w, "early")
+		return
+	}
+	if err := hh.SetResponseHeaders(w, hh.Retarget("#main")); err != nil {
+		return
+	}
+
+	_ = tmpl.Execute(w, "body")
+	if err := hh.SetResponseHeaders(w, hh.PushURL("/late")); err != nil { // want "SetResponseHeaders is called after w was written to at line 31"
+		return
+	}
+}
+
+func conflicts(w http.ResponseWriter) error {
+	return hh.SetResponseHeaders(w,
+		hh.Redirect("/a"),
+		hh.Location("/b"), // want "Location conflicts with Redirect applied in the same call"
+		hh.Retarget("#a"),
+		hh.Retarget("#b"), // want "Retarget is applied more than once"
+		hh.PreventPushURL(),
+		hh.PushURL("/c"), // want "PushURL conflicts with PreventPushURL applied in the same call"
+	)
+}
+
+func eventNames(w http.ResponseWriter) error {
+	return hh.SetResponseHeaders(w,
+		hh.Trigger(hh.TriggerImmediately, "saved, reload"),
+		hh.Trigger(hh.TriggerAfterSwap, "item saved"), // want "event name \"item saved\" contains whitespace"
+		hh.Trigger(hh.TriggerAfterSettle, `say"hi"`),  // want "contains the illegal character"
+		hh.TriggerWithDetail(hh.TriggerImmediately,
+			hh.TriggerEvent{Name: "showAlert"},
+			hh.TriggerEvent{Name: "a,b"}, // want "event name \"a,b\" contains a comma"
+		),
+	)
+}
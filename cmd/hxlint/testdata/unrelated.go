@@ -0,0 +1,10 @@
+package testdata
+
+import "net/http"
+
+// SetResponseHeaders is not the htmxheaders function, so it is not checked.
+func SetResponseHeaders(w http.ResponseWriter) error { return nil }
+
+func notImported(w http.ResponseWriter) {
+	_ = SetResponseHeaders(w)
+}
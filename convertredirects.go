@@ -0,0 +1,129 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// RedirectMode determines which HTMX header a standard redirect is converted into.
+type RedirectMode int
+
+const (
+	RedirectModeRedirect RedirectMode = iota // use HX-Redirect, causing a full page navigation.
+	RedirectModeLocation                     // use HX-Location, navigating without a full page reload.
+)
+
+// RedirectPolicy chooses the RedirectMode used when converting a redirect issued during an htmx request.
+// The location parameter is the value of the Location header set by the handler.
+type RedirectPolicy func(r *http.Request, location string) RedirectMode
+
+// AlwaysRedirect is a RedirectPolicy that always converts redirects into HX-Redirect.
+func AlwaysRedirect(*http.Request, string) RedirectMode {
+	return RedirectModeRedirect
+}
+
+// AlwaysLocation is a RedirectPolicy that always converts redirects into HX-Location.
+func AlwaysLocation(*http.Request, string) RedirectMode {
+	return RedirectModeLocation
+}
+
+// LocationWhenSameOrigin is a RedirectPolicy that converts redirects to the same host into HX-Location
+// and redirects to any other host into HX-Redirect, as HX-Location cannot navigate across origins.
+func LocationWhenSameOrigin(r *http.Request, location string) RedirectMode {
+	u, err := url.Parse(location)
+	if err != nil {
+		return RedirectModeRedirect
+	}
+	if u.Host == "" || u.Host == r.Host {
+		return RedirectModeLocation
+	}
+	return RedirectModeRedirect
+}
+
+// ConvertRedirects returns a http.Handler that converts standard 3xx redirects issued by next
+// during htmx requests into a 200 response with either the HX-Redirect or the HX-Location header.
+//
+// Without this, the browser follows the redirect invisibly and htmx swaps the content of the
+// redirected page into the target element. The policy chooses which header is used for each
+// redirect; a nil policy uses AlwaysRedirect. Requests not made by htmx are left untouched.
+//
+// Example usage:
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(":3000", hh.ConvertRedirects(mux, hh.LocationWhenSameOrigin))
+//
+// Note:
+//
+//	The body written alongside the redirect, such as the one written by http.Redirect, is discarded.
+//	304 Not Modified responses are not redirects and are passed through unchanged.
+func ConvertRedirects(next http.Handler, policy RedirectPolicy) http.Handler {
+	if policy == nil {
+		policy = AlwaysRedirect
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsHTMXRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&redirectWriter{ResponseWriter: w, r: r, policy: policy}, r)
+	})
+}
+
+// redirectWriter intercepts redirect responses and replaces them with the appropriate HX header.
+type redirectWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	policy      RedirectPolicy
+	wroteHeader bool
+	intercepted bool
+}
+
+func (rw *redirectWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	location := rw.Header().Get("Location")
+	if !isRedirectStatus(code) || location == "" {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	rw.intercepted = true
+	h := rw.Header()
+	h.Del("Location")
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+
+	decorator := Redirect(location)
+	if rw.policy(rw.r, location) == RedirectModeLocation {
+		decorator = Location(location)
+	}
+
+	if err := decorator(rw.ResponseWriter); err != nil {
+		rw.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.ResponseWriter.WriteHeader(http.StatusOK)
+}
+
+func (rw *redirectWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.intercepted {
+		return len(b), nil
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (rw *redirectWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func isRedirectStatus(code int) bool {
+	return code >= 300 && code < 400 && code != http.StatusNotModified
+}
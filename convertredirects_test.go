@@ -0,0 +1,85 @@
+package htmxheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func redirectingHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", status)
+	})
+}
+
+func TestConvertRedirectsUsesHXRedirectByDefault(t *testing.T) {
+	r := httptest.NewRequest("GET", "/fragment", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+
+	hh.ConvertRedirects(redirectingHandler(http.StatusFound), nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("HX-Redirect"))
+	assert.Empty(t, w.Header().Get("Location"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestConvertRedirectsWithLocationPolicy(t *testing.T) {
+	r := httptest.NewRequest("POST", "/fragment", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+
+	hh.ConvertRedirects(redirectingHandler(http.StatusSeeOther), hh.AlwaysLocation).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("HX-Location"))
+	assert.Empty(t, w.Header().Get("HX-Redirect"))
+}
+
+func TestConvertRedirectsIgnoresNonHTMXRequests(t *testing.T) {
+	r := httptest.NewRequest("GET", "/page", nil)
+	w := httptest.NewRecorder()
+
+	hh.ConvertRedirects(redirectingHandler(http.StatusFound), nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/login", w.Header().Get("Location"))
+	assert.Empty(t, w.Header().Get("HX-Redirect"))
+}
+
+func TestConvertRedirectsPassesThroughOtherResponses(t *testing.T) {
+	r := httptest.NewRequest("GET", "/fragment", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	hh.ConvertRedirects(handler, nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Header().Get("HX-Redirect"))
+}
+
+func TestLocationWhenSameOrigin(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/fragment", nil)
+
+	testCases := []struct {
+		location string
+		expected hh.RedirectMode
+	}{
+		{"/login", hh.RedirectModeLocation},
+		{"http://example.com/login", hh.RedirectModeLocation},
+		{"https://auth.example.org/login", hh.RedirectModeRedirect},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.location, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hh.LocationWhenSameOrigin(r, tc.location))
+		})
+	}
+}
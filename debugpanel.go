@@ -0,0 +1,228 @@
+package htmxheaders
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DebugPanelOptions configures a DebugPanel.
+type DebugPanelOptions struct {
+	Size    int    // the number of exchanges kept, defaults to 50
+	Overlay int    // the number of exchanges shown in an overlay appended to full page responses, no overlay if zero
+	Path    string // the path the DebugPanel is served at, linked to from the overlay
+}
+
+// Exchange is an htmx request and its response, as recorded by a DebugPanel.
+type Exchange struct {
+	Time        time.Time     `json:"time"`
+	Method      string        `json:"method"`
+	URL         string        `json:"url"`
+	Request     Request       `json:"request"`
+	Status      int           `json:"status"`
+	Size        int           `json:"size"`
+	Duration    time.Duration `json:"duration"`
+	Response    Response      `json:"response"`
+	DecodeError string        `json:"decodeError,omitempty"` // the error decoding the response headers, if any
+}
+
+// DebugPanel keeps the most recent htmx exchanges in memory for inspection during development.
+//
+// Requests are recorded by the Record middleware, and the DebugPanel is a http.Handler serving an inspector
+// page listing them, or JSON when requested with ?format=json. The response headers are decoded with
+// ParseResponse, so HX-Location contexts, swap modifiers and trigger events are shown expanded.
+//
+// The DebugPanel is intended for development only: the inspector shows request and response headers,
+// including any HX-Prompt responses, and should not be served in production.
+//
+// Example usage:
+//
+//	panel := hh.NewDebugPanel(hh.DebugPanelOptions{Overlay: 5, Path: "/_htmx"})
+//	mux.Handle("/_htmx", panel)
+//	http.ListenAndServe(":3000", panel.Record(mux))
+type DebugPanel struct {
+	opts DebugPanelOptions
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+	full      bool
+}
+
+// NewDebugPanel creates a DebugPanel with the given options.
+func NewDebugPanel(opts DebugPanelOptions) *DebugPanel {
+	if opts.Size <= 0 {
+		opts.Size = 50
+	}
+	return &DebugPanel{opts: opts, exchanges: make([]Exchange, opts.Size)}
+}
+
+// Record returns a http.Handler recording each htmx request handled by next. If DebugPanelOptions.Overlay
+// is set, an overlay showing the most recent exchanges is appended to successful text/html responses to
+// requests not made by htmx.
+func (d *DebugPanel) Record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := ParseRequest(r)
+		overlay := !req.Enabled && d.opts.Overlay > 0
+		if !req.Enabled && !overlay {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := newStatusWriter(w)
+		if overlay {
+			sw.beforeWriteHeader = func(status int) {
+				if canAppendHTML(status, sw.Header()) {
+					sw.Header().Del("Content-Length")
+				}
+			}
+		}
+
+		next.ServeHTTP(sw, r)
+		sw.finish()
+
+		if overlay {
+			if canAppendHTML(sw.status, sw.header) && r.Method != http.MethodHead {
+				_ = debugOverlayTemplate.Execute(sw, d.overlayData())
+			}
+			return
+		}
+
+		exchange := Exchange{
+			Time:     sw.start,
+			Method:   r.Method,
+			URL:      r.URL.RequestURI(),
+			Request:  req,
+			Status:   sw.status,
+			Size:     sw.size,
+			Duration: sw.elapsed(),
+		}
+		resp, err := ParseResponse(sw.header)
+		exchange.Response = resp
+		if err != nil {
+			exchange.DecodeError = err.Error()
+		}
+		d.add(exchange)
+	})
+}
+
+func (d *DebugPanel) add(exchange Exchange) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.exchanges[d.next] = exchange
+	d.next = (d.next + 1) % len(d.exchanges)
+	if d.next == 0 {
+		d.full = true
+	}
+}
+
+// Exchanges returns the recorded exchanges, most recent first.
+func (d *DebugPanel) Exchanges() []Exchange {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	count := d.next
+	if d.full {
+		count = len(d.exchanges)
+	}
+
+	exchanges := make([]Exchange, 0, count)
+	for i := 1; i <= count; i++ {
+		exchanges = append(exchanges, d.exchanges[(d.next-i+len(d.exchanges))%len(d.exchanges)])
+	}
+	return exchanges
+}
+
+// ServeHTTP serves the inspector page, or the exchanges as JSON if the format query parameter is "json".
+func (d *DebugPanel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	exchanges := d.Exchanges()
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(exchanges)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = debugPanelTemplate.Execute(w, exchanges)
+}
+
+func (d *DebugPanel) overlayData() any {
+	exchanges := d.Exchanges()
+	if len(exchanges) > d.opts.Overlay {
+		exchanges = exchanges[:d.opts.Overlay]
+	}
+	return struct {
+		Path      string
+		Exchanges []Exchange
+	}{d.opts.Path, exchanges}
+}
+
+// debugResponseJSON returns the decoded response headers as indented JSON.
+func debugResponseJSON(resp Response) string {
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+var debugFuncs = template.FuncMap{
+	"responseJSON": debugResponseJSON,
+	"duration":     func(d time.Duration) string { return d.Round(time.Microsecond).String() },
+	"clock":        func(t time.Time) string { return t.Format("15:04:05.000") },
+}
+
+var debugPanelTemplate = template.Must(template.New("panel").Funcs(debugFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>htmx exchanges</title>
+<style>
+body { font: 14px system-ui, sans-serif; margin: 2rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: .5rem; text-align: left; vertical-align: top; }
+pre { margin: 0; font-size: 12px; white-space: pre-wrap; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>htmx exchanges</h1>
+{{if not .}}<p>No htmx requests have been recorded.</p>{{else}}
+<table>
+<thead><tr><th>Time</th><th>Request</th><th>Status</th><th>Size</th><th>Duration</th><th>HX request headers</th><th>HX response headers</th></tr></thead>
+<tbody>
+{{range .}}<tr>
+<td>{{clock .Time}}</td>
+<td>{{.Method}} {{.URL}}</td>
+<td>{{.Status}}</td>
+<td>{{.Size}}</td>
+<td>{{duration .Duration}}</td>
+<td><pre>{{with .Request}}{{if .Boosted}}boosted
+{{end}}{{if .HistoryRestoreRequest}}history restore
+{{end}}{{with .Target}}target: {{.}}
+{{end}}{{with .Trigger}}trigger: {{.}}
+{{end}}{{with .TriggerName}}trigger name: {{.}}
+{{end}}{{with .CurrentURL}}current URL: {{.}}
+{{end}}{{end}}</pre></td>
+<td><pre>{{responseJSON .Response}}</pre>{{with .DecodeError}}<p class="error">{{.}}</p>{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+var debugOverlayTemplate = template.Must(template.New("overlay").Funcs(debugFuncs).Parse(`<div id="htmx-debug-overlay" style="position:fixed;bottom:0;right:0;z-index:2147483647;max-width:32rem;max-height:40vh;overflow:auto;background:#111;color:#eee;font:12px monospace;padding:.5rem;opacity:.9">
+<strong>htmx</strong>{{with .Path}} <a href="{{.}}" style="color:#8cf">inspector</a>{{end}}
+{{range .Exchanges}}<div>{{clock .Time}} {{.Method}} {{.URL}} &rarr; {{.Status}} ({{duration .Duration}}){{with .Response.Retarget}} retarget:{{.}}{{end}}{{with .Response.Reswap}} reswap:{{.}}{{end}}{{range .Response.Trigger}} trigger:{{.Name}}{{end}}{{range .Response.TriggerAfterSwap}} trigger:{{.Name}}{{end}}{{range .Response.TriggerAfterSettle}} trigger:{{.Name}}{{end}}</div>
+{{else}}<div>No htmx requests yet.</div>
+{{end}}</div>
+`))
@@ -0,0 +1,106 @@
+package htmxheaders_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func htmxGet(path string) *http.Request {
+	r := httptest.NewRequest("GET", path, nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Target", "rows")
+	return r
+}
+
+func TestDebugPanelRecordsExchanges(t *testing.T) {
+	panel := hh.NewDebugPanel(hh.DebugPanelOptions{Size: 2})
+	handler := panel.Record(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w,
+			hh.LocationWithContext("/orders", hh.LocationContext{Target: "#main"}),
+			hh.TriggerWithDetail(hh.TriggerAfterSwap, hh.TriggerEvent{Name: "saved", Detail: "ok"}),
+		)
+		require.NoError(t, err)
+		_, _ = io.WriteString(w, "<tr></tr>")
+	}))
+
+	for i := 1; i <= 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), htmxGet(fmt.Sprintf("/rows?page=%d", i)))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	exchanges := panel.Exchanges()
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, "/rows?page=3", exchanges[0].URL)
+	assert.Equal(t, "/rows?page=2", exchanges[1].URL)
+	assert.Equal(t, http.StatusOK, exchanges[0].Status)
+	assert.Equal(t, 9, exchanges[0].Size)
+	assert.Equal(t, "rows", exchanges[0].Request.Target)
+	assert.Equal(t, "#main", exchanges[0].Response.Location.Target)
+	assert.Equal(t, []hh.TriggerEvent{{Name: "saved", Detail: "ok"}}, exchanges[0].Response.TriggerAfterSwap)
+}
+
+func TestDebugPanelServesInspector(t *testing.T) {
+	panel := hh.NewDebugPanel(hh.DebugPanelOptions{})
+	handler := panel.Record(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w,
+			hh.Retarget("#errors"),
+			hh.TriggerWithDetail(hh.TriggerImmediately, hh.TriggerEvent{Name: "invalid", Detail: "q"}),
+		)
+		require.NoError(t, err)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), htmxGet("/rows?q=<script>"))
+
+	w := httptest.NewRecorder()
+	panel.ServeHTTP(w, httptest.NewRequest("GET", "/_htmx", nil))
+	assert.Contains(t, w.Body.String(), "GET /rows?q=&lt;script&gt;")
+	assert.Contains(t, w.Body.String(), "&#34;retarget&#34;: &#34;#errors&#34;")
+	assert.NotContains(t, w.Body.String(), "<script>")
+
+	w = httptest.NewRecorder()
+	panel.ServeHTTP(w, httptest.NewRequest("GET", "/_htmx?format=json", nil))
+	assert.Contains(t, w.Body.String(), `"request": {
+      "enabled": true,
+      "target": "rows"
+    }`)
+	assert.Contains(t, w.Body.String(), `"trigger": [
+        {
+          "name": "invalid",
+          "detail": "q"
+        }
+      ]`)
+	var exchanges []hh.Exchange
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exchanges))
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, "#errors", exchanges[0].Response.Retarget)
+}
+
+func TestDebugPanelOverlay(t *testing.T) {
+	panel := hh.NewDebugPanel(hh.DebugPanelOptions{Overlay: 1, Path: "/_htmx"})
+	handler := panel.Record(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hh.IsHTMXRequest(r) {
+			w.Header().Set("Content-Length", "13")
+		}
+		_, _ = io.WriteString(w, "<html></html>")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), htmxGet("/rows?page=1"))
+	handler.ServeHTTP(httptest.NewRecorder(), htmxGet("/rows?page=2"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "<html></html><div id=\"htmx-debug-overlay\""))
+	assert.Contains(t, body, `href="/_htmx"`)
+	assert.Contains(t, body, "/rows?page=2")
+	assert.NotContains(t, body, "/rows?page=1")
+	assert.Empty(t, w.Header().Get("Content-Length"))
+}
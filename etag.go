@@ -0,0 +1,111 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FragmentETag returns a strong ETag for the rendered body of a response to the request.
+//
+// The same URL often returns a fragment to htmx requests and a full page otherwise, or different fragments
+// depending on the element being targeted, so the HX-Request and HX-Target headers are included in the hash.
+func FragmentETag(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Header.Get("HX-Request")))
+	h.Write([]byte{0})
+	h.Write([]byte(r.Header.Get("HX-Target")))
+	h.Write([]byte{0})
+	h.Write(body)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// ServeFragment renders the response body into a buffer, sets the ETag header and writes the body,
+// or responds with 304 Not Modified if the request's If-None-Match header matches the ETag.
+//
+// This avoids sending identical fragments to clients polling an endpoint. The Vary header is extended with
+// HX-Request and HX-Target so caches keep the responses for each separately. Any HX headers already set on the
+// response are left intact, and are sent with both the full and the 304 response.
+//
+// Parameters:
+//
+//	w: http.ResponseWriter - The response writer to which the fragment will be written.
+//	r: *http.Request - The request, whose If-None-Match, HX-Request and HX-Target headers are used.
+//	render: func(io.Writer) error - A function that writes the body of the response.
+//
+// Returns:
+//
+//	error: The error returned by the render function, in which case nothing has been written to w,
+//	       or any error writing the body.
+//
+// Example usage:
+//
+//	_ = hh.SetResponseHeaders(w, hh.Trigger(hh.TriggerImmediately, "statsPolled"))
+//	err := hh.ServeFragment(w, r, func(w io.Writer) error {
+//	    return tmpl.ExecuteTemplate(w, "stats", stats)
+//	})
+//	if err != nil {
+//	    // Handle error
+//	}
+//
+// Note:
+//
+//	Conditional requests are only answered for GET and HEAD requests; for other methods the body is always written.
+func ServeFragment(w http.ResponseWriter, r *http.Request, render func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+
+	etag := FragmentETag(r, buf.Bytes())
+	h := w.Header()
+	h.Set("ETag", etag)
+	addVary(h, "HX-Request", "HX-Target")
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag, using the weak comparison.
+// https://www.rfc-editor.org/rfc/rfc9110#section-13.1.2
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds the header names to the Vary header, skipping any already present.
+func addVary(h http.Header, names ...string) {
+	for _, name := range names {
+		if !headerContainsToken(h, "Vary", name) {
+			h.Add("Vary", name)
+		}
+	}
+}
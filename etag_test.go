@@ -0,0 +1,91 @@
+package htmxheaders_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func renderString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+func TestServeFragmentWritesBodyAndETag(t *testing.T) {
+	r := httptest.NewRequest("GET", "/stats", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+
+	err := hh.ServeFragment(w, r, renderString("<p>42</p>"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<p>42</p>", w.Body.String())
+	assert.Equal(t, hh.FragmentETag(r, []byte("<p>42</p>")), w.Header().Get("ETag"))
+	assert.Equal(t, []string{"HX-Request", "HX-Target"}, w.Header().Values("Vary"))
+}
+
+func TestServeFragmentAnswersIfNoneMatch(t *testing.T) {
+	r := httptest.NewRequest("GET", "/stats", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("If-None-Match", `"other", W/`+hh.FragmentETag(r, []byte("<p>42</p>")))
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Accept-Encoding, HX-Request")
+
+	err := hh.SetResponseHeaders(w, hh.Trigger(hh.TriggerImmediately, "statsPolled"))
+	require.NoError(t, err)
+	err = hh.ServeFragment(w, r, renderString("<p>42</p>"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "statsPolled", w.Header().Get("HX-Trigger"))
+	assert.Equal(t, []string{"Accept-Encoding, HX-Request", "HX-Target"}, w.Header().Values("Vary"))
+}
+
+func TestFragmentETagVariesWithHTMXHeaders(t *testing.T) {
+	body := []byte("<p>42</p>")
+
+	page := httptest.NewRequest("GET", "/stats", nil)
+	fragment := httptest.NewRequest("GET", "/stats", nil)
+	fragment.Header.Set("HX-Request", "true")
+	targeted := httptest.NewRequest("GET", "/stats", nil)
+	targeted.Header.Set("HX-Request", "true")
+	targeted.Header.Set("HX-Target", "stats")
+
+	assert.NotEqual(t, hh.FragmentETag(page, body), hh.FragmentETag(fragment, body))
+	assert.NotEqual(t, hh.FragmentETag(fragment, body), hh.FragmentETag(targeted, body))
+	assert.Equal(t, hh.FragmentETag(targeted, body), hh.FragmentETag(targeted.Clone(targeted.Context()), body))
+}
+
+func TestServeFragmentIgnoresIfNoneMatchForPost(t *testing.T) {
+	r := httptest.NewRequest("POST", "/stats", nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+
+	err := hh.ServeFragment(w, r, renderString("<p>42</p>"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<p>42</p>", w.Body.String())
+}
+
+func TestServeFragmentReturnsRenderErrors(t *testing.T) {
+	r := httptest.NewRequest("GET", "/stats", nil)
+	w := httptest.NewRecorder()
+	renderErr := errors.New("template error")
+
+	err := hh.ServeFragment(w, r, func(io.Writer) error { return renderErr })
+
+	assert.ErrorIs(t, err, renderErr)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+}
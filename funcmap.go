@@ -0,0 +1,193 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// FuncMap returns functions for producing htmx attribute values in html/template templates:
+//
+//	hxVals     encodes its argument, or pairs of keys and values, as the JSON object of an hx-vals attribute
+//	hxHeaders  encodes a map[string]string, or pairs of header names and values, as the JSON object of an hx-headers attribute
+//	hxSwap     formats a Swap, SwapSpec or hx-swap string, validated with ParseSwapSpec, as an hx-swap attribute
+//	hxTrigger  formats a TriggerSpec, TriggerOn or hx-trigger string, validated with ParseTriggerSpec, as an hx-trigger attribute
+//
+// The values returned are plain strings, which html/template escapes for the attribute they are written to,
+// so they are safe to use with either single or double quoted attributes.
+//
+// Example usage:
+//
+//	tmpl := template.Must(template.New("row").Funcs(hh.FuncMap()).Parse(
+//	    `<button hx-post="/rows/{{.ID}}" hx-vals='{{hxVals "id" .ID "name" .Name}}' hx-swap="{{hxSwap .Swap}}">Save</button>`,
+//	))
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"hxVals":    hxVals,
+		"hxHeaders": hxHeaders,
+		"hxSwap":    hxSwap,
+		"hxTrigger": hxTrigger,
+	}
+}
+
+// hxVals encodes a single value, or pairs of keys and values, as a JSON object.
+func hxVals(args ...any) (string, error) {
+	if len(args) == 1 {
+		data, err := json.Marshal(args[0])
+		if err != nil {
+			return "", fmt.Errorf("hxVals: %w", err)
+		}
+		if !bytes.HasPrefix(data, []byte("{")) {
+			return "", fmt.Errorf("hxVals: %T does not encode to a JSON object", args[0])
+		}
+		return string(data), nil
+	}
+
+	data, err := encodePairs(args, func(key string, value any) (any, error) { return value, nil })
+	if err != nil {
+		return "", fmt.Errorf("hxVals: %w", err)
+	}
+	return data, nil
+}
+
+// hxHeaders encodes a map of header names and values, or pairs of header names and values, as a JSON object.
+func hxHeaders(args ...any) (string, error) {
+	if len(args) == 1 {
+		headers, ok := args[0].(map[string]string)
+		if !ok {
+			return "", fmt.Errorf("hxHeaders: expected a map[string]string, not %T", args[0])
+		}
+		for name, value := range headers {
+			if err := validateHeaderField(name, value); err != nil {
+				return "", fmt.Errorf("hxHeaders: %w", err)
+			}
+		}
+		data, err := json.Marshal(headers)
+		return string(data), err
+	}
+
+	data, err := encodePairs(args, func(name string, value any) (any, error) {
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		return s, validateHeaderField(name, s)
+	})
+	if err != nil {
+		return "", fmt.Errorf("hxHeaders: %w", err)
+	}
+	return data, nil
+}
+
+// hxSwap formats a Swap, SwapSpec or hx-swap string.
+func hxSwap(swap any) (string, error) {
+	switch swap := swap.(type) {
+	case Swap:
+		return swap.String(), nil
+	case SwapSpec:
+		return swap.String(), nil
+	case *SwapSpec:
+		if swap == nil {
+			return "", errors.New("hxSwap: nil *SwapSpec")
+		}
+		return swap.String(), nil
+	case string:
+		spec, err := ParseSwapSpec(swap)
+		if err != nil {
+			return "", fmt.Errorf("hxSwap: %w", err)
+		}
+		return spec.String(), nil
+	default:
+		return "", fmt.Errorf("hxSwap: expected a Swap, SwapSpec or string, not %T", swap)
+	}
+}
+
+// hxTrigger formats a TriggerSpec, TriggerOn or hx-trigger string.
+func hxTrigger(trigger any) (string, error) {
+	var spec TriggerSpec
+	switch trigger := trigger.(type) {
+	case TriggerSpec:
+		spec = trigger
+	case TriggerOn:
+		spec = TriggerSpec{trigger}
+	case string:
+		parsed, err := ParseTriggerSpec(trigger)
+		if err != nil {
+			return "", fmt.Errorf("hxTrigger: %w", err)
+		}
+		spec = parsed
+	default:
+		return "", fmt.Errorf("hxTrigger: expected a TriggerSpec, TriggerOn or string, not %T", trigger)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return "", fmt.Errorf("hxTrigger: %w", err)
+	}
+	return spec.String(), nil
+}
+
+// encodePairs encodes pairs of string keys and values, converted by the value function, as a JSON object,
+// keeping the order of the pairs.
+func encodePairs(args []any, value func(key string, value any) (any, error)) (string, error) {
+	if len(args)%2 != 0 {
+		return "", errors.New("expected a single value or pairs of keys and values")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	seen := make(map[string]bool, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("key %v must be a string, not %T", args[i], args[i])
+		}
+		if seen[key] {
+			return "", fmt.Errorf("duplicate key %q", key)
+		}
+		seen[key] = true
+
+		v, err := value(key, args[i+1])
+		if err != nil {
+			return "", err
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return "", err
+		}
+		encodedValue, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("error encoding %q: %w", key, err)
+		}
+
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.Write(encodedKey)
+		sb.WriteString(":")
+		sb.Write(encodedValue)
+	}
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+// validateHeaderField checks that the header name is a valid token and the value contains no control characters,
+// so that htmx can send them with its request.
+func validateHeaderField(name, value string) error {
+	if name == "" {
+		return errors.New("header name must not be empty")
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	for _, r := range value {
+		if r < ' ' && r != '\t' || r == 0x7f {
+			return fmt.Errorf("header %s has a value containing control characters", name)
+		}
+	}
+	return nil
+}
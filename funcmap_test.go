@@ -0,0 +1,95 @@
+package htmxheaders_test
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func executeTemplate(t *testing.T, text string, data any) (string, error) {
+	t.Helper()
+	tmpl := template.Must(template.New("test").Funcs(hh.FuncMap()).Parse(text))
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, data)
+	return sb.String(), err
+}
+
+func TestFuncMapHXVals(t *testing.T) {
+	out, err := executeTemplate(t, `<button hx-vals='{{hxVals "id" .ID "name" .Name}}'></button>`, map[string]any{
+		"ID":   7,
+		"Name": `O'Brien "<script>"`,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `<button hx-vals='{&#34;id&#34;:7,&#34;name&#34;:&#34;O&#39;Brien \&#34;\u003cscript\u003e\&#34;&#34;}'></button>`, out)
+}
+
+func TestFuncMapHXValsStruct(t *testing.T) {
+	out, err := executeTemplate(t, `<div hx-vals="{{hxVals .}}"></div>`, struct {
+		Page int `json:"page"`
+	}{Page: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, `<div hx-vals="{&#34;page&#34;:2}"></div>`, out)
+}
+
+func TestFuncMapHXValsErrors(t *testing.T) {
+	for _, text := range []string{`{{hxVals 1}}`, `{{hxVals "id"}}`, `{{hxVals 1 2}}`, `{{hxVals "id" 1 "id" 2}}`} {
+		_, err := executeTemplate(t, text, nil)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestFuncMapHXHeaders(t *testing.T) {
+	out, err := executeTemplate(t, `<div hx-headers='{{hxHeaders "X-CSRF-Token" .}}'></div>`, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, `<div hx-headers='{&#34;X-CSRF-Token&#34;:&#34;abc123&#34;}'></div>`, out)
+
+	out, err = executeTemplate(t, `{{hxHeaders .}}`, map[string]string{"X-Tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, `{&#34;X-Tenant&#34;:&#34;acme&#34;}`, out)
+
+	_, err = executeTemplate(t, `{{hxHeaders "X Bad" "value"}}`, nil)
+	assert.Error(t, err)
+	_, err = executeTemplate(t, `{{hxHeaders "X-Good" .}}`, "line\nbreak")
+	assert.Error(t, err)
+}
+
+func TestFuncMapHXSwap(t *testing.T) {
+	tests := []struct {
+		data     any
+		expected string
+	}{
+		{hh.SwapOuterHTML, "outerHTML"},
+		{hh.SwapSpec{Swap: hh.SwapBeforeEnd, Scroll: "bottom"}, "beforeend scroll:bottom"},
+		{"afterbegin  settle:1s", "afterbegin settle:1s"},
+	}
+
+	for _, test := range tests {
+		out, err := executeTemplate(t, `{{hxSwap .}}`, test.data)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, out)
+	}
+
+	_, err := executeTemplate(t, `{{hxSwap .}}`, "sideways")
+	assert.Error(t, err)
+}
+
+func TestFuncMapHXTrigger(t *testing.T) {
+	out, err := executeTemplate(t, `<input hx-trigger="{{hxTrigger .}}">`, hh.TriggerSpec{
+		{Event: "keyup", Filter: "key=='Enter'", Changed: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `<input hx-trigger="keyup[key==&#39;Enter&#39;] changed">`, out)
+
+	out, err = executeTemplate(t, `{{hxTrigger .}}`, "every 5s")
+	require.NoError(t, err)
+	assert.Equal(t, "every 5s", out)
+
+	_, err = executeTemplate(t, `{{hxTrigger .}}`, hh.TriggerOn{Event: "click", Queue: "sometimes"})
+	assert.Error(t, err)
+}
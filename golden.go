@@ -0,0 +1,142 @@
+package htmxheaders
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// GoldenRecord is an htmx exchange recorded by RecordGolden, written as a line of JSON.
+type GoldenRecord struct {
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`                  // the request URI, including the query
+	Header      map[string]string `json:"header"`                // the HX request headers, and the Content-Type if there is a body
+	RequestBody string            `json:"requestBody,omitempty"` // the body of the request, if recorded, such as submitted form values
+	Status      int               `json:"status"`
+	Response    Response          `json:"response"` // the decoded HX response headers
+	BodyHash    string            `json:"bodyHash"` // the hex encoded SHA-256 hash of the response body
+}
+
+// GoldenOptions configures the RecordGolden middleware.
+type GoldenOptions struct {
+	// Redact lists the request headers whose values are recorded as "[REDACTED]".
+	// Defaults to HX-Prompt, which holds the user's response to an hx-prompt, if nil.
+	Redact []string
+
+	// RequestBody records the body of each request, such as submitted form values, so that it is sent again when
+	// replayed. It is off by default, as bodies may hold passwords or CSRF tokens that should not be committed.
+	RequestBody bool
+}
+
+// RecordGolden returns a http.Handler writing each htmx request handled by next, with the decisions made by
+// its response, to w as a line of JSON, for replay with htmxtest.ReplayGolden. Requests not made by htmx are not
+// recorded. The values of redacted headers are replaced, and request bodies are only recorded when enabled by
+// GoldenOptions.RequestBody, so that golden files can be committed as testdata.
+//
+// When request bodies are recorded they are read into memory, so RecordGolden is intended for recording sessions
+// during development rather than production traffic.
+//
+// Example usage:
+//
+//	f, err := os.Create("testdata/orders.golden.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer f.Close()
+//	http.ListenAndServe(":3000", hh.RecordGolden(mux, f, hh.GoldenOptions{RequestBody: true}))
+func RecordGolden(next http.Handler, w io.Writer, opts GoldenOptions) http.Handler {
+	redact := opts.Redact
+	if redact == nil {
+		redact = []string{"HX-Prompt"}
+	}
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !IsHTMXRequest(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		var requestBody []byte
+		if opts.RequestBody && r.Body != nil {
+			requestBody, _ = io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(requestBody))
+		}
+
+		hash := sha256.New()
+		sw := newStatusWriter(&hashWriter{ResponseWriter: rw, hash: hash})
+		next.ServeHTTP(sw, r)
+		sw.finish()
+
+		record := GoldenRecord{
+			Method:      r.Method,
+			Path:        r.URL.RequestURI(),
+			Header:      goldenRequestHeader(r.Header, len(requestBody) > 0, redact),
+			RequestBody: string(requestBody),
+			Status:      sw.status,
+			BodyHash:    hex.EncodeToString(hash.Sum(nil)),
+		}
+		record.Response, _ = ParseResponse(sw.header)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(record)
+	})
+}
+
+// hashWriter hashes the body written to the response.
+type hashWriter struct {
+	http.ResponseWriter
+	hash io.Writer
+}
+
+func (hw *hashWriter) Write(b []byte) (int, error) {
+	n, err := hw.ResponseWriter.Write(b)
+	hw.hash.Write(b[:n])
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (hw *hashWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+func goldenRequestHeader(h http.Header, hasBody bool, redact []string) map[string]string {
+	header := map[string]string{}
+	for _, name := range hxHeaderNames(h) {
+		header[hxHeaderName(name)] = redactValue(name, h.Get(name), redact)
+	}
+	if contentType := h.Get("Content-Type"); hasBody && contentType != "" {
+		header["Content-Type"] = contentType
+	}
+	return header
+}
+
+// ReadGolden reads the records written by RecordGolden.
+func ReadGolden(r io.Reader) ([]GoldenRecord, error) {
+	var records []GoldenRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record GoldenRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("error decoding golden record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading golden records: %w", err)
+	}
+	return records, nil
+}
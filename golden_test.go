@@ -0,0 +1,90 @@
+package htmxheaders_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// ordersHandler retargets to the errors when the quantity is missing, and triggers saved otherwise.
+func ordersHandler(savedEvent string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("quantity") == "" {
+			err := hh.SetResponseHeaders(w, hh.Retarget("#errors"), hh.Reswap(hh.SwapOuterHTML))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = io.WriteString(w, `<p id="errors">Quantity is required</p>`)
+			return
+		}
+
+		err := hh.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerAfterSwap, hh.TriggerEvent{Name: savedEvent, Detail: map[string]any{"quantity": r.FormValue("quantity")}}))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, "<tr><td>"+r.FormValue("quantity")+"</td></tr>")
+	})
+}
+
+func recordOrders(t *testing.T, opts hh.GoldenOptions) *bytes.Buffer {
+	t.Helper()
+	var golden bytes.Buffer
+	recorder := hh.RecordGolden(ordersHandler("saved"), &golden, opts)
+
+	for _, form := range []url.Values{{"quantity": {"2"}}, {}} {
+		r := httptest.NewRequest("POST", "/orders", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		r.Header.Set("HX-Target", "orders")
+		r.Header.Set("HX-Prompt", "my secret answer")
+		recorder.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	recorder.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	return &golden
+}
+
+func TestRecordGolden(t *testing.T) {
+	records, err := hh.ReadGolden(recordOrders(t, hh.GoldenOptions{RequestBody: true}))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "POST", records[0].Method)
+	assert.Equal(t, "/orders", records[0].Path)
+	assert.Equal(t, map[string]string{
+		"HX-Request":   "true",
+		"HX-Target":    "orders",
+		"HX-Prompt":    "[REDACTED]",
+		"Content-Type": "application/x-www-form-urlencoded",
+	}, records[0].Header)
+	assert.Equal(t, "quantity=2", records[0].RequestBody)
+	assert.Equal(t, http.StatusOK, records[0].Status)
+	assert.Equal(t, "saved", records[0].Response.TriggerAfterSwap[0].Name)
+	assert.Len(t, records[0].BodyHash, 64)
+	assert.Equal(t, "#errors", records[1].Response.Retarget)
+}
+
+func TestRecordGoldenOmitsRequestBodyByDefault(t *testing.T) {
+	records, err := hh.ReadGolden(recordOrders(t, hh.GoldenOptions{Redact: []string{"HX-Target"}}))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Empty(t, records[0].RequestBody)
+	assert.Equal(t, map[string]string{"HX-Request": "true", "HX-Target": "[REDACTED]", "HX-Prompt": "my secret answer"}, records[0].Header)
+	assert.Equal(t, "saved", records[0].Response.TriggerAfterSwap[0].Name)
+}
+
+func TestReadGoldenInvalid(t *testing.T) {
+	_, err := hh.ReadGolden(strings.NewReader("{}\nnot json\n"))
+
+	assert.ErrorContains(t, err, "line 2")
+}
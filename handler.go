@@ -0,0 +1,76 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Result describes the response to a request handled by Handler.
+type Result struct {
+	Status     int                     // the status code of the response, http.StatusOK if zero
+	Body       func(w io.Writer) error // renders the body of the response, which may be nil
+	Decorators []DecoratorFunction     // the decorators applied to the response
+	OOB        []OOBFragment           // out of band fragments appended to the body
+}
+
+// Handler returns a http.Handler calling fn and writing the Result it returns.
+//
+// The body and OOB fragments are rendered into a buffer before anything is written, so that the response is
+// always written in the right order: the headers set by the decorators, then the status, then the body.
+// Errors returned by fn, by rendering the body, or by the decorators are written with WriteError, so an
+// *HXError is shown as it describes, and any other error results in a 500 Internal Server Error.
+//
+// A Content-Type of text/html is set unless a decorator sets another.
+//
+// Example usage:
+//
+//	mux.Handle("POST /todos", hh.Handler(func(r *http.Request) (hh.Result, error) {
+//	    todo, err := todos.Create(r.Context(), r.FormValue("title"))
+//	    if err != nil {
+//	        return hh.Result{}, err
+//	    }
+//	    return hh.Result{
+//	        Status:     http.StatusCreated,
+//	        Body:       func(w io.Writer) error { return templates.ExecuteTemplate(w, "todo", todo) },
+//	        Decorators: []hh.DecoratorFunction{hh.Trigger(hh.TriggerAfterSwap, "todoCreated")},
+//	        OOB:        []hh.OOBFragment{{Target: "#todo-count", Swap: hh.SwapInnerHTML, HTML: strconv.Itoa(todos.Count())}},
+//	    }, nil
+//	}))
+func Handler(fn func(r *http.Request) (Result, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := fn(r)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		var body bytes.Buffer
+		if result.Body != nil {
+			if err := result.Body(&body); err != nil {
+				WriteError(w, err)
+				return
+			}
+		}
+		if err := RenderOOB(&body, result.OOB...); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if err := SetResponseHeaders(w, result.Decorators...); err != nil {
+			_ = RemoveHXHeaders(w)
+			WriteError(w, err)
+			return
+		}
+
+		status := result.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if w.Header().Get("Content-Type") == "" && body.Len() > 0 {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		_, _ = body.WriteTo(w)
+	})
+}
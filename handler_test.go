@@ -0,0 +1,92 @@
+package htmxheaders_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestHandlerWritesResult(t *testing.T) {
+	handler := hh.Handler(func(r *http.Request) (hh.Result, error) {
+		return hh.Result{
+			Status:     http.StatusCreated,
+			Body:       renderString("<li>Milk</li>"),
+			Decorators: []hh.DecoratorFunction{hh.Trigger(hh.TriggerAfterSwap, "todoCreated")},
+			OOB:        []hh.OOBFragment{{Target: "#count", Swap: hh.SwapInnerHTML, HTML: "3"}},
+		}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "todoCreated", w.Header().Get("HX-Trigger-After-Swap"))
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<li>Milk</li><div hx-swap-oob="innerHTML:#count">3</div>`, w.Body.String())
+}
+
+func TestHandlerWithoutBody(t *testing.T) {
+	handler := hh.Handler(func(r *http.Request) (hh.Result, error) {
+		return hh.Result{Decorators: []hh.DecoratorFunction{hh.Refresh()}}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("HX-Refresh"))
+	assert.Empty(t, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestHandlerWritesHXError(t *testing.T) {
+	handler := hh.Handler(func(r *http.Request) (hh.Result, error) {
+		return hh.Result{}, hh.NewHXError(errors.New("title is required"), hh.Retarget("#errors"))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "#errors", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "title is required", w.Body.String())
+}
+
+func TestHandlerRenderErrorWritesNothingPartial(t *testing.T) {
+	handler := hh.Handler(func(r *http.Request) (hh.Result, error) {
+		return hh.Result{
+			Body: func(w io.Writer) error {
+				_, _ = io.WriteString(w, "<li>half")
+				return errors.New("template failed")
+			},
+			Decorators: []hh.DecoratorFunction{hh.PushURL("/todos")},
+		}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "half")
+	assert.Empty(t, w.Header().Get("HX-Push-Url"))
+}
+
+func TestHandlerDecoratorError(t *testing.T) {
+	handler := hh.Handler(func(r *http.Request) (hh.Result, error) {
+		return hh.Result{
+			Body:       renderString("<li>Milk</li>"),
+			Decorators: []hh.DecoratorFunction{hh.PushURL("/todos"), hh.Retarget("")},
+		}, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("HX-Push-Url"))
+}
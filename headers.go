@@ -0,0 +1,60 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// responseHeaderDecorators maps each HTMX response header to the name of the decorator that sets it.
+var responseHeaderDecorators = map[string]string{
+	"HX-Location":             "Location",
+	"HX-Push-Url":             "PushURL",
+	"HX-Redirect":             "Redirect",
+	"HX-Refresh":              "Refresh",
+	"HX-Replace-Url":          "ReplaceURL",
+	"HX-Reswap":               "Reswap",
+	"HX-Retarget":             "Retarget",
+	"HX-Reselect":             "Reselect",
+	"HX-Trigger":              "Trigger",
+	"HX-Trigger-After-Settle": "TriggerAfterSettle",
+	"HX-Trigger-After-Swap":   "TriggerAfterSwap",
+}
+
+// DecoratorName returns the name of the decorator setting the given HTMX response header,
+// such as "Retarget" for HX-Retarget. Headers not set by a decorator of this package,
+// such as those set with AddCustomHeader, are returned as given.
+func DecoratorName(header string) string {
+	for key, name := range responseHeaderDecorators {
+		if strings.EqualFold(key, header) {
+			return name
+		}
+	}
+	return header
+}
+
+// hxHeaderNames returns the names of the headers starting with HX-, sorted and in canonical form.
+func hxHeaderNames(h http.Header) []string {
+	var names []string
+	for name := range h {
+		if len(name) > 3 && strings.EqualFold(name[:3], "HX-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hxHeaderName returns the header name as it is written in the HTMX documentation, such as HX-Push-Url
+// rather than the canonical Hx-Push-Url used by http.Header.
+func hxHeaderName(name string) string {
+	for key := range responseHeaderDecorators {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	if len(name) > 3 && strings.EqualFold(name[:3], "HX-") {
+		return "HX-" + name[3:]
+	}
+	return name
+}
@@ -0,0 +1,26 @@
+package htmxheaders_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestDecoratorName(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{"HX-Retarget", "Retarget"},
+		{"Hx-Push-Url", "PushURL"},
+		{"HX-Trigger-After-Settle", "TriggerAfterSettle"},
+		{"HX-Custom", "HX-Custom"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hh.DecoratorName(tc.header))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DecoratorInfo describes a decorator being applied to a response, and the changes it makes to the headers.
+type DecoratorInfo struct {
+	Index   int         // the position of the decorator in the call to SetResponseHeaders
+	Name    string      // the name of the decorator, such as "Retarget", or its function name if it sets no header or several
+	Header  string      // the first header set by the decorator in alphabetical order, empty if it sets no header
+	Value   string      // the value of Header
+	Headers http.Header // every header set or changed by the decorator, with its values
+	Removed []string    // the headers removed by the decorator
+}
+
+// Hook observes the decorators applied by SetResponseHeaders.
+//
+// BeforeApply is called before the changes made by each decorator are applied to the response; returning an
+// error prevents them, and any remaining decorators, from being applied, and is returned by SetResponseHeaders.
+// AfterApply is called once the changes have been applied. OnError is called when a decorator, or a
+// BeforeApply hook, returns an error.
+//
+// Each decorator is called once, with a copy of the response headers, so that the DecoratorInfo passed to
+// BeforeApply holds the headers it sets, with values built on any existing headers it reads. Headers set to the
+// value they already had are not reported as changed.
+type Hook interface {
+	BeforeApply(w http.ResponseWriter, info DecoratorInfo) error
+	AfterApply(w http.ResponseWriter, info DecoratorInfo)
+	OnError(w http.ResponseWriter, info DecoratorInfo, err error)
+}
+
+// HookFuncs is a Hook calling the functions provided, any of which may be nil.
+type HookFuncs struct {
+	Before func(w http.ResponseWriter, info DecoratorInfo) error
+	After  func(w http.ResponseWriter, info DecoratorInfo)
+	Error  func(w http.ResponseWriter, info DecoratorInfo, err error)
+}
+
+// BeforeApply calls h.Before if it is set.
+func (h HookFuncs) BeforeApply(w http.ResponseWriter, info DecoratorInfo) error {
+	if h.Before == nil {
+		return nil
+	}
+	return h.Before(w, info)
+}
+
+// AfterApply calls h.After if it is set.
+func (h HookFuncs) AfterApply(w http.ResponseWriter, info DecoratorInfo) {
+	if h.After != nil {
+		h.After(w, info)
+	}
+}
+
+// OnError calls h.Error if it is set.
+func (h HookFuncs) OnError(w http.ResponseWriter, info DecoratorInfo, err error) {
+	if h.Error != nil {
+		h.Error(w, info, err)
+	}
+}
+
+var globalHooks struct {
+	mu    sync.RWMutex
+	hooks []*Hook
+}
+
+// RegisterHook registers a hook called for the decorators applied by every call to SetResponseHeaders,
+// including calls made through a Builder. The returned function unregisters the hook.
+//
+// Example usage:
+//
+//	unregister := hh.RegisterHook(hh.HookFuncs{
+//	    After: func(w http.ResponseWriter, info hh.DecoratorInfo) {
+//	        slog.Debug("decorator applied", "name", info.Name, "header", info.Header, "value", info.Value)
+//	    },
+//	})
+//	defer unregister()
+func RegisterHook(h Hook) (unregister func()) {
+	registered := &h
+
+	globalHooks.mu.Lock()
+	globalHooks.hooks = append(globalHooks.hooks, registered)
+	globalHooks.mu.Unlock()
+
+	return func() {
+		globalHooks.mu.Lock()
+		defer globalHooks.mu.Unlock()
+		for i, hook := range globalHooks.hooks {
+			if hook == registered {
+				globalHooks.hooks = append(globalHooks.hooks[:i:i], globalHooks.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Builder applies decorators with its own hooks, in addition to those registered with RegisterHook.
+type Builder struct {
+	hooks []Hook
+}
+
+// NewBuilder creates a Builder calling the given hooks.
+//
+// Example usage:
+//
+//	audited := hh.NewBuilder(auditHook)
+//	err := audited.SetResponseHeaders(w, hh.Redirect("/account"))
+//	if err != nil {
+//	    // Handle error
+//	}
+func NewBuilder(hooks ...Hook) *Builder {
+	return &Builder{hooks: hooks}
+}
+
+// Use adds hooks to the Builder.
+func (b *Builder) Use(hooks ...Hook) {
+	b.hooks = append(b.hooks, hooks...)
+}
+
+// SetResponseHeaders applies the decorators to w as the package level SetResponseHeaders does,
+// calling the registered hooks followed by the hooks of the Builder.
+func (b *Builder) SetResponseHeaders(w http.ResponseWriter, decorators ...DecoratorFunction) error {
+	globalHooks.mu.RLock()
+	hooks := make([]Hook, 0, len(globalHooks.hooks)+len(b.hooks))
+	for _, h := range globalHooks.hooks {
+		hooks = append(hooks, *h)
+	}
+	globalHooks.mu.RUnlock()
+
+	return applyDecorators(w, append(hooks, b.hooks...), decorators)
+}
+
+// applyDecorators applies each decorator in turn, calling the hooks around each one.
+// When there are hooks, each decorator is applied to a stagedWriter, and the changes it made to the headers
+// are passed to BeforeApply and only applied to the response once every hook has allowed them.
+func applyDecorators(w http.ResponseWriter, hooks []Hook, decorators []DecoratorFunction) error {
+	for i, decorator := range decorators {
+		if len(hooks) == 0 {
+			if err := decorator(w); err != nil {
+				return err
+			}
+			continue
+		}
+
+		staged := newStagedWriter(w)
+		err := decorator(staged)
+		info := staged.describe(i, decorator)
+		if err == nil {
+			for _, h := range hooks {
+				if err = h.BeforeApply(w, info); err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			for _, h := range hooks {
+				h.OnError(w, info, err)
+			}
+			return err
+		}
+
+		staged.commit()
+		for _, h := range hooks {
+			h.AfterApply(w, info)
+		}
+	}
+	return nil
+}
+
+// stagedWriter is a http.ResponseWriter holding a copy of the headers of the response, so that the changes made
+// by a decorator can be inspected before they are applied. Writing the status or body applies the changes first.
+type stagedWriter struct {
+	http.ResponseWriter
+	header http.Header
+}
+
+func newStagedWriter(w http.ResponseWriter) *stagedWriter {
+	return &stagedWriter{ResponseWriter: w, header: w.Header().Clone()}
+}
+
+func (sw *stagedWriter) Header() http.Header {
+	return sw.header
+}
+
+func (sw *stagedWriter) WriteHeader(code int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *stagedWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (sw *stagedWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// changes returns the headers set or changed, and the names of the headers removed, since the copy was made.
+func (sw *stagedWriter) changes() (http.Header, []string) {
+	original := sw.ResponseWriter.Header()
+
+	set := http.Header{}
+	for name, values := range sw.header {
+		if !slices.Equal(original[name], values) {
+			set[name] = slices.Clone(values)
+		}
+	}
+
+	var removed []string
+	for name := range original {
+		if _, ok := sw.header[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return set, removed
+}
+
+// commit applies the changes to the headers of the response.
+func (sw *stagedWriter) commit() {
+	set, removed := sw.changes()
+	h := sw.ResponseWriter.Header()
+	for name, values := range set {
+		h[name] = values
+	}
+	for _, name := range removed {
+		delete(h, name)
+	}
+}
+
+// describe returns the DecoratorInfo of the decorator applied to the stagedWriter.
+func (sw *stagedWriter) describe(index int, decorator DecoratorFunction) DecoratorInfo {
+	set, removed := sw.changes()
+	info := DecoratorInfo{Index: index, Headers: set, Removed: removed}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	if len(names) != 1 {
+		// A decorator setting several headers, such as HXError.Apply, is not named after any one of them.
+		info.Name = funcName(decorator)
+	}
+	if len(names) == 0 {
+		return info
+	}
+
+	sort.Strings(names)
+	info.Header = hxHeaderName(names[0])
+	info.Value = set.Get(names[0])
+	if info.Name == "" {
+		info.Name = DecoratorName(names[0])
+	}
+	return info
+}
+
+// funcName returns the name of the function without its package path, or the suffix given to method values.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return ""
+	}
+	name := strings.TrimSuffix(f.Name(), "-fm")
+	return name[strings.LastIndex(name, "/")+1:]
+}
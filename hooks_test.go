@@ -0,0 +1,156 @@
+package htmxheaders_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// recordingHook records the calls made to it.
+type recordingHook struct {
+	calls []string
+	infos []hh.DecoratorInfo
+}
+
+func (h *recordingHook) BeforeApply(w http.ResponseWriter, info hh.DecoratorInfo) error {
+	h.calls = append(h.calls, "before "+info.Name)
+	return nil
+}
+
+func (h *recordingHook) AfterApply(w http.ResponseWriter, info hh.DecoratorInfo) {
+	h.calls = append(h.calls, "after "+info.Name)
+	h.infos = append(h.infos, info)
+}
+
+func (h *recordingHook) OnError(w http.ResponseWriter, info hh.DecoratorInfo, err error) {
+	h.calls = append(h.calls, "error "+info.Name+": "+err.Error())
+}
+
+func TestRegisterHook(t *testing.T) {
+	hook := &recordingHook{}
+	unregister := hh.RegisterHook(hook)
+
+	w := httptest.NewRecorder()
+	err := hh.SetResponseHeaders(w, hh.Retarget("#errors"), hh.Trigger(hh.TriggerAfterSwap, "saved"))
+	require.NoError(t, err)
+
+	unregister()
+	require.NoError(t, hh.SetResponseHeaders(w, hh.Refresh()))
+
+	assert.Equal(t, []string{"before Retarget", "after Retarget", "before TriggerAfterSwap", "after TriggerAfterSwap"}, hook.calls)
+	assert.Equal(t, []hh.DecoratorInfo{
+		{Index: 0, Name: "Retarget", Header: "HX-Retarget", Value: "#errors", Headers: http.Header{"Hx-Retarget": {"#errors"}}},
+		{Index: 1, Name: "TriggerAfterSwap", Header: "HX-Trigger-After-Swap", Value: "saved", Headers: http.Header{"Hx-Trigger-After-Swap": {"saved"}}},
+	}, hook.infos)
+	assert.Equal(t, "true", w.Header().Get("HX-Refresh"))
+}
+
+func TestBuilderHookCanPreventDecorators(t *testing.T) {
+	errNoRedirects := errors.New("redirects are not allowed")
+	policy := hh.HookFuncs{
+		Before: func(w http.ResponseWriter, info hh.DecoratorInfo) error {
+			if info.Name == "Redirect" {
+				return errNoRedirects
+			}
+			return nil
+		},
+	}
+	hook := &recordingHook{}
+	builder := hh.NewBuilder(policy)
+	builder.Use(hook)
+
+	w := httptest.NewRecorder()
+	err := builder.SetResponseHeaders(w, hh.PushURL("/orders"), hh.Redirect("/login"), hh.Refresh())
+
+	assert.ErrorIs(t, err, errNoRedirects)
+	assert.Equal(t, "/orders", w.Header().Get("HX-Push-Url"))
+	assert.Empty(t, w.Header().Get("HX-Redirect"))
+	assert.Empty(t, w.Header().Get("HX-Refresh"))
+	assert.Equal(t, []string{"before PushURL", "after PushURL", "error Redirect: redirects are not allowed"}, hook.calls)
+}
+
+func TestBuilderHookOnDecoratorError(t *testing.T) {
+	hook := &recordingHook{}
+	failing := hh.TriggerWithDetail(hh.TriggerImmediately, hh.TriggerEvent{Name: "bad", Detail: make(chan int)})
+
+	err := hh.NewBuilder(hook).SetResponseHeaders(httptest.NewRecorder(), failing)
+
+	assert.Error(t, err)
+	require.Len(t, hook.calls, 1)
+	assert.Contains(t, hook.calls[0], "error ")
+}
+
+func TestBuilderHookDescribesDecoratorsSettingNoHXHeader(t *testing.T) {
+	hook := &recordingHook{}
+	w := httptest.NewRecorder()
+
+	err := hh.NewBuilder(hook).SetResponseHeaders(w, hh.RemoveHXHeaders)
+	require.NoError(t, err)
+
+	require.Len(t, hook.infos, 1)
+	assert.Equal(t, "htmxheaders.RemoveHXHeaders", hook.infos[0].Name)
+	assert.Empty(t, hook.infos[0].Header)
+}
+
+func TestBuilderHookNamesDecoratorsSettingSeveralHeadersByFunction(t *testing.T) {
+	hook := &recordingHook{}
+	w := httptest.NewRecorder()
+	hxErr := hh.NewHXError(errors.New("invalid"), hh.Retarget("#form"), hh.Reswap(hh.SwapOuterHTML))
+
+	err := hh.NewBuilder(hook).SetResponseHeaders(w, hxErr.Apply, hh.Retarget("#main"))
+	require.NoError(t, err)
+
+	require.Len(t, hook.infos, 2)
+	assert.Equal(t, "htmxheaders.(*HXError).Apply", hook.infos[0].Name)
+	assert.Equal(t, "HX-Reswap", hook.infos[0].Header)
+	assert.Equal(t, "Retarget", hook.infos[1].Name)
+}
+
+func TestBuilderHookAppliesEachDecoratorOnce(t *testing.T) {
+	calls := 0
+	appendEvent := func(w http.ResponseWriter) error {
+		calls++
+		w.Header().Set("HX-Trigger", w.Header().Get("HX-Trigger")+", closeModal")
+		w.Header().Set("HX-Retarget", "#modal")
+		return nil
+	}
+
+	hook := &recordingHook{}
+	w := httptest.NewRecorder()
+	w.Header().Set("HX-Trigger", "saved")
+	w.Header().Set("HX-Reswap", "outerHTML")
+
+	err := hh.NewBuilder(hook).SetResponseHeaders(w, appendEvent, hh.RemoveHXHeaders)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	require.Len(t, hook.infos, 2)
+	assert.Equal(t, "HX-Retarget", hook.infos[0].Header)
+	assert.Equal(t, http.Header{"Hx-Trigger": {"saved, closeModal"}, "Hx-Retarget": {"#modal"}}, hook.infos[0].Headers)
+	assert.Equal(t, []string{"Hx-Reswap", "Hx-Retarget"}, hook.infos[1].Removed)
+	assert.Equal(t, "saved, closeModal", w.Header().Get("HX-Trigger"))
+	assert.Empty(t, w.Header().Get("HX-Retarget"))
+}
+
+func TestBuilderHookPreventingDecoratorLeavesHeadersUnchanged(t *testing.T) {
+	deny := hh.HookFuncs{
+		Before: func(w http.ResponseWriter, info hh.DecoratorInfo) error {
+			if info.Headers.Get("HX-Trigger") != "" {
+				return errors.New("no triggers")
+			}
+			return nil
+		},
+	}
+	w := httptest.NewRecorder()
+
+	err := hh.NewBuilder(deny).SetResponseHeaders(w, hh.NewHXError(errors.New("invalid"), hh.Retarget("#form"), hh.Trigger(hh.TriggerImmediately, "invalid")).Apply)
+
+	assert.Error(t, err)
+	assert.Empty(t, w.Header().Get("HX-Retarget"))
+	assert.Empty(t, w.Header().Get("HX-Trigger"))
+}
@@ -0,0 +1,158 @@
+// Package htmxtest provides helpers for testing handlers using the htmxheaders package.
+package htmxtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// GoldenDiff is a difference between a GoldenRecord and the response to replaying its request.
+type GoldenDiff struct {
+	Index  int    // the position of the record in the golden file
+	Method string // the method of the request
+	Path   string // the path of the request
+	Field  string // the field that differs: "status", "body", or the JSON name of the Response field, such as "retarget"
+	Want   string // the recorded value
+	Got    string // the value returned when replayed
+}
+
+func (d GoldenDiff) String() string {
+	return fmt.Sprintf("#%d %s %s: %s: want %s, got %s", d.Index, d.Method, d.Path, d.Field, d.Want, d.Got)
+}
+
+// ReplayGolden sends the request of each record written by htmxheaders.RecordGolden and read from r to h,
+// returning the differences between the recorded status, HX response headers and body hash and those of the
+// response. No differences means the handler makes the same decisions as when the records were made.
+//
+// Redacted headers are sent with their redacted value, and requests recorded without their body are sent
+// with an empty body. An error is returned if a record cannot be read or its method or path are invalid.
+//
+// Example usage, in a test:
+//
+//	f, err := os.Open("testdata/orders.golden.jsonl")
+//	require.NoError(t, err)
+//	defer f.Close()
+//
+//	diffs, err := htmxtest.ReplayGolden(newRouter(), f)
+//	require.NoError(t, err)
+//	for _, diff := range diffs {
+//	    t.Error(diff)
+//	}
+func ReplayGolden(h http.Handler, r io.Reader) ([]GoldenDiff, error) {
+	records, err := hh.ReadGolden(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []GoldenDiff
+	for i, record := range records {
+		req, err := http.NewRequest(record.Method, record.Path, strings.NewReader(record.RequestBody))
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		// Match the requests made by httptest.NewRequest, which handlers may have been recorded with.
+		req.Host, req.RemoteAddr, req.RequestURI = "example.com", "192.0.2.1:1234", record.Path
+		for name, value := range record.Header {
+			req.Header.Set(name, value)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		got := hh.GoldenRecord{Status: w.Code}
+		got.Response, _ = hh.ParseResponse(w.Header())
+		sum := sha256.Sum256(w.Body.Bytes())
+		got.BodyHash = hex.EncodeToString(sum[:])
+
+		for _, diff := range diffGoldenRecords(record, got) {
+			diff.Index, diff.Method, diff.Path = i, record.Method, record.Path
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+// ReplayGoldenFile replays the golden file at path against h with ReplayGolden, reporting each difference
+// with t.Error. The test is stopped with t.Fatalf, naming the file, if it cannot be read or replayed.
+//
+// Example usage, in a test:
+//
+//	htmxtest.ReplayGoldenFile(t, newRouter(), "testdata/orders.golden.jsonl")
+func ReplayGoldenFile(t testing.TB, h http.Handler, path string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("golden file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	diffs, err := ReplayGolden(h, f)
+	if err != nil {
+		t.Fatalf("golden file %s: %v", path, err)
+	}
+	for _, diff := range diffs {
+		t.Errorf("golden file %s: %s", path, diff)
+	}
+}
+
+// diffGoldenRecords compares the status, body hash and each field of the decoded response headers.
+func diffGoldenRecords(want, got hh.GoldenRecord) []GoldenDiff {
+	var diffs []GoldenDiff
+	if want.Status != got.Status {
+		diffs = append(diffs, GoldenDiff{Field: "status", Want: fmt.Sprint(want.Status), Got: fmt.Sprint(got.Status)})
+	}
+
+	wantFields, gotFields := responseFields(want.Response), responseFields(got.Response)
+	names := make([]string, 0, len(wantFields)+len(gotFields))
+	for name := range wantFields {
+		names = append(names, name)
+	}
+	for name := range gotFields {
+		if _, ok := wantFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if wantFields[name] != gotFields[name] {
+			diffs = append(diffs, GoldenDiff{Field: name, Want: orUnset(wantFields[name]), Got: orUnset(gotFields[name])})
+		}
+	}
+
+	if want.BodyHash != got.BodyHash {
+		diffs = append(diffs, GoldenDiff{Field: "body", Want: want.BodyHash, Got: got.BodyHash})
+	}
+	return diffs
+}
+
+// responseFields returns the JSON encoding of each field of the response that is set.
+func responseFields(resp hh.Response) map[string]string {
+	data, _ := json.Marshal(resp)
+	var raw map[string]json.RawMessage
+	_ = json.Unmarshal(data, &raw)
+
+	fields := make(map[string]string, len(raw))
+	for name, value := range raw {
+		fields[name] = string(value)
+	}
+	return fields
+}
+
+func orUnset(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package htmxtest_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+	"github.com/thisisthemurph/htmxheaders/htmxtest"
+)
+
+// ordersHandler retargets to the errors when the quantity is missing, and triggers savedEvent otherwise.
+func ordersHandler(savedEvent string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("quantity") == "" {
+			err := hh.SetResponseHeaders(w, hh.Retarget("#errors"), hh.Reswap(hh.SwapOuterHTML))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = io.WriteString(w, `<p id="errors">Quantity is required</p>`)
+			return
+		}
+
+		err := hh.SetResponseHeaders(w, hh.Trigger(hh.TriggerAfterSwap, savedEvent))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, "<tr><td>"+r.FormValue("quantity")+"</td></tr>")
+	})
+}
+
+func recordOrders(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var golden bytes.Buffer
+	recorder := hh.RecordGolden(ordersHandler("saved"), &golden, hh.GoldenOptions{RequestBody: true})
+
+	for _, form := range []url.Values{{"quantity": {"2"}}, {}} {
+		r := httptest.NewRequest("POST", "/orders", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("HX-Request", "true")
+		recorder.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	return &golden
+}
+
+func TestReplayGoldenWithoutChanges(t *testing.T) {
+	diffs, err := htmxtest.ReplayGolden(ordersHandler("saved"), recordOrders(t))
+
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+}
+
+func TestReplayGoldenReportsDiffs(t *testing.T) {
+	diffs, err := htmxtest.ReplayGolden(ordersHandler("orderSaved"), recordOrders(t))
+
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, 0, diffs[0].Index)
+	assert.Equal(t, "triggerAfterSwap", diffs[0].Field)
+	assert.Contains(t, diffs[0].Want, `"saved"`)
+	assert.Contains(t, diffs[0].Got, `"orderSaved"`)
+	assert.Contains(t, diffs[0].String(), "#0 POST /orders: triggerAfterSwap: want")
+}
+
+func TestReplayGoldenInvalid(t *testing.T) {
+	_, err := htmxtest.ReplayGolden(ordersHandler("saved"), strings.NewReader("not json\n"))
+
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestReplayGoldenMalformedRequest(t *testing.T) {
+	for _, record := range []string{
+		`{"method":"GET /","path":"/orders","status":200}`,
+		`{"method":"GET","path":"/orders\u0000","status":200}`,
+	} {
+		_, err := htmxtest.ReplayGolden(ordersHandler("saved"), strings.NewReader(record+"\n"))
+
+		assert.ErrorContains(t, err, "record 0", record)
+	}
+}
+
+func TestReplayGoldenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.golden.jsonl")
+	require.NoError(t, os.WriteFile(path, recordOrders(t).Bytes(), 0o644))
+
+	htmxtest.ReplayGoldenFile(t, ordersHandler("saved"), path)
+}
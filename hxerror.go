@@ -0,0 +1,133 @@
+package htmxheaders
+
+import (
+	"errors"
+	"html"
+	"io"
+	"net/http"
+)
+
+// HXError is an error carrying the response that shows it, allowing domain code to decide where an error
+// is displayed without access to the http.ResponseWriter.
+//
+// HXError.Apply is a DecoratorFunction setting its headers, and WriteError writes its complete response.
+type HXError struct {
+	Err        error               // the underlying error
+	Decorators []DecoratorFunction // the decorators applied to the response
+	Status     int                 // the status code of the response, http.StatusOK if zero
+	Fragment   string              // the HTML written as the body, the escaped error message if empty
+}
+
+// NewHXError returns an HXError wrapping err, which is shown by applying the decorators.
+//
+// The response has a status of 200 OK unless changed with WithStatus, as htmx does not swap the content of
+// 4xx and 5xx responses by default.
+//
+// Example usage:
+//
+//	if order.Quantity < 1 {
+//	    return hh.NewHXError(ErrInvalidQuantity, hh.Retarget("#form-errors"), hh.Reswap(hh.SwapOuterHTML)).
+//	        WithFragment(`<ul id="form-errors"><li>Quantity must be at least 1</li></ul>`)
+//	}
+func NewHXError(err error, decorators ...DecoratorFunction) *HXError {
+	return &HXError{Err: err, Decorators: decorators}
+}
+
+// Error returns the message of the underlying error.
+func (e *HXError) Error() string {
+	if e.Err == nil {
+		return "htmx error"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *HXError) Unwrap() error {
+	return e.Err
+}
+
+// WithStatus sets the status code of the response and returns the HXError.
+func (e *HXError) WithStatus(code int) *HXError {
+	e.Status = code
+	return e
+}
+
+// WithFragment sets the HTML written as the body of the response and returns the HXError.
+func (e *HXError) WithFragment(html string) *HXError {
+	e.Fragment = html
+	return e
+}
+
+// Apply applies the decorators of the error to w, stopping at the first decorator returning an error.
+// Apply is a DecoratorFunction, so the error can be passed to SetResponseHeaders.
+func (e *HXError) Apply(w http.ResponseWriter) error {
+	for _, decorator := range e.Decorators {
+		if err := decorator(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteResponse writes the response for the error: its headers, status and fragment.
+// If the headers could not be set, a 500 Internal Server Error is written instead and the error is returned.
+func (e *HXError) WriteResponse(w http.ResponseWriter) error {
+	if err := SetResponseHeaders(w, e.Decorators...); err != nil {
+		_ = RemoveHXHeaders(w)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	fragment := e.Fragment
+	if fragment == "" {
+		fragment = html.EscapeString(e.Error())
+	}
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := io.WriteString(w, fragment)
+	return err
+}
+
+// WriteError writes the response for err. If err is, or wraps, an *HXError, its response is written, otherwise
+// a 500 Internal Server Error is written without revealing the error message.
+func WriteError(w http.ResponseWriter, err error) {
+	var hxErr *HXError
+	if errors.As(err, &hxErr) {
+		_ = hxErr.WriteResponse(w)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// ErrorHandlerFunc is a http.Handler returning an error, which is written with WriteError.
+// The handler must not have written a response when returning an error.
+//
+// Example usage:
+//
+//	mux.Handle("POST /orders", hh.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//	    order, err := orders.Create(r.Context(), r.FormValue("product"), r.FormValue("quantity"))
+//	    if err != nil {
+//	        return err // an *hh.HXError returned by orders.Create is shown in #form-errors
+//	    }
+//
+//	    // Render into a buffer, so that a template error is written with WriteError rather than a partial body.
+//	    var buf bytes.Buffer
+//	    if err := templates.ExecuteTemplate(&buf, "order", order); err != nil {
+//	        return err
+//	    }
+//	    _, _ = buf.WriteTo(w)
+//	    return nil
+//	}))
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls f(w, r), writing any error returned with WriteError.
+func (f ErrorHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		WriteError(w, err)
+	}
+}
@@ -0,0 +1,84 @@
+package htmxheaders_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+var errInvalidQuantity = errors.New("quantity must be at least 1")
+
+func TestHXErrorWrapsError(t *testing.T) {
+	err := fmt.Errorf("creating order: %w", hh.NewHXError(errInvalidQuantity, hh.Retarget("#form-errors")))
+
+	var hxErr *hh.HXError
+	require.True(t, errors.As(err, &hxErr))
+	assert.ErrorIs(t, err, errInvalidQuantity)
+	assert.Equal(t, "creating order: quantity must be at least 1", err.Error())
+}
+
+func TestHXErrorApplyIsDecorator(t *testing.T) {
+	w := httptest.NewRecorder()
+	hxErr := hh.NewHXError(errInvalidQuantity, hh.Retarget("#form-errors"), hh.Reswap(hh.SwapOuterHTML))
+
+	err := hh.SetResponseHeaders(w, hxErr.Apply)
+
+	require.NoError(t, err)
+	assert.Equal(t, "#form-errors", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "outerHTML", w.Header().Get("HX-Reswap"))
+}
+
+func TestErrorHandlerFuncWritesHXError(t *testing.T) {
+	handler := hh.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		hxErr := hh.NewHXError(errInvalidQuantity, hh.Retarget("#form-errors")).
+			WithStatus(http.StatusUnprocessableEntity).
+			WithFragment(`<p id="form-errors">Invalid quantity</p>`)
+		return fmt.Errorf("creating order: %w", hxErr)
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "#form-errors", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, `<p id="form-errors">Invalid quantity</p>`, w.Body.String())
+}
+
+func TestErrorHandlerFuncDefaultFragmentIsEscaped(t *testing.T) {
+	handler := hh.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return hh.NewHXError(errors.New("<b>bad</b>"))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "&lt;b&gt;bad&lt;/b&gt;", w.Body.String())
+}
+
+func TestErrorHandlerFuncHidesOtherErrors(t *testing.T) {
+	handler := hh.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database password is hunter2")
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+}
+
+func TestHXErrorWithInvalidDecorator(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := hh.NewHXError(errInvalidQuantity, hh.Retarget("")).WriteResponse(w)
+
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
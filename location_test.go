@@ -50,3 +50,19 @@ func TestLocationWithContext(t *testing.T) {
 		t.Errorf("Expected swap: %s, got swap: $%s", context.Swap, data.Swap)
 	}
 }
+
+func TestLocationWithContextEncodesSwapAsString(t *testing.T) {
+	w := httptest.NewRecorder()
+	context := hh.LocationContext{Target: "#my-target", Swap: hh.SwapOuterHTML}
+	err := hh.SetResponseHeaders(w, hh.LocationWithContext("/some/path", context))
+
+	if err != nil {
+		t.Errorf("LocationWithContext returned an unexpected error: %v", err)
+	}
+
+	expected := `{"target":"#my-target","swap":"outerHTML","path":"/some/path"}`
+	header := w.Header().Get("HX-Location")
+	if header != expected {
+		t.Errorf("Expected header HX-Location to have value %s, got %s", expected, header)
+	}
+}
@@ -0,0 +1,151 @@
+package htmxheaders
+
+import (
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+)
+
+// redactedValue replaces the value of redacted headers in log records.
+const redactedValue = "[REDACTED]"
+
+// LogOptions configures the LogHTMX middleware.
+type LogOptions struct {
+	// Logger is the logger records are written to, defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Sample reports whether the request should be logged. All requests are logged if nil.
+	Sample func(r *http.Request) bool
+
+	// Redact lists the request and response headers whose values are replaced with "[REDACTED]".
+	// Defaults to HX-Prompt, which holds the user's response to an hx-prompt, if nil.
+	Redact []string
+
+	// Level chooses the level of each record. Defaults to LevelForStatus if nil.
+	Level func(r *http.Request, status int) slog.Level
+}
+
+// SampleRate returns a LogOptions.Sample function logging the given fraction of requests, between 0 and 1.
+func SampleRate(rate float64) func(r *http.Request) bool {
+	return func(*http.Request) bool {
+		return rand.Float64() < rate
+	}
+}
+
+// LevelForStatus logs server errors at slog.LevelError, client errors at slog.LevelWarn
+// and all other responses at slog.LevelInfo.
+func LevelForStatus(_ *http.Request, status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LogHTMX returns a http.Handler that logs each request with the HTMX request headers it was sent with,
+// the decorators applied to the response, identified by the HX headers they set, and the status and latency.
+//
+// Example usage:
+//
+//	handler := hh.LogHTMX(mux, hh.LogOptions{
+//	    Logger: slog.New(slog.NewJSONHandler(os.Stderr, nil)),
+//	    Sample: hh.SampleRate(0.1),
+//	})
+//
+// A record for an htmx request that failed validation looks like:
+//
+//	level=WARN msg="htmx exchange" method=POST path=/contact status=422 latency=1.2ms size=87
+//	request.htmx=true request.boosted=false request.target=contact-form request.trigger=contact-form
+//	decorators.Retarget=#errors decorators.Reswap=outerHTML
+//
+// Note:
+//
+//	The headers are logged as they were when the response was written, so headers
+//	changed afterwards, which are not sent to the client, are not logged.
+func LogHTMX(next http.Handler, opts LogOptions) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := opts.Level
+	if level == nil {
+		level = LevelForStatus
+	}
+	redact := opts.Redact
+	if redact == nil {
+		redact = []string{"HX-Prompt"}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.Sample != nil && !opts.Sample(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := newStatusWriter(w)
+		next.ServeHTTP(sw, r)
+		sw.finish()
+
+		ctx := r.Context()
+		lvl := level(r, sw.status)
+		if !logger.Enabled(ctx, lvl) {
+			return
+		}
+
+		logger.LogAttrs(ctx, lvl, "htmx exchange",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", sw.status),
+			slog.Duration("latency", sw.elapsed()),
+			slog.Int("size", sw.size),
+			requestLogGroup(ParseRequest(r), redact),
+			decoratorLogGroup(sw.header, redact),
+		)
+	})
+}
+
+func requestLogGroup(req Request, redact []string) slog.Attr {
+	attrs := []any{
+		slog.Bool("htmx", req.Enabled),
+		slog.Bool("boosted", req.Boosted),
+	}
+
+	optional := []struct{ key, header, value string }{
+		{"target", "HX-Target", req.Target},
+		{"trigger", "HX-Trigger", req.Trigger},
+		{"trigger_name", "HX-Trigger-Name", req.TriggerName},
+		{"current_url", "HX-Current-URL", req.CurrentURL},
+		{"prompt", "HX-Prompt", req.Prompt},
+	}
+	for _, o := range optional {
+		if o.value != "" {
+			attrs = append(attrs, slog.String(o.key, redactValue(o.header, o.value, redact)))
+		}
+	}
+	if req.HistoryRestoreRequest {
+		attrs = append(attrs, slog.Bool("history_restore", true))
+	}
+
+	return slog.Group("request", attrs...)
+}
+
+func decoratorLogGroup(h http.Header, redact []string) slog.Attr {
+	var attrs []any
+	for _, name := range hxHeaderNames(h) {
+		attrs = append(attrs, slog.String(DecoratorName(name), redactValue(name, h.Get(name), redact)))
+	}
+	return slog.Group("decorators", attrs...)
+}
+
+func redactValue(header, value string, redact []string) string {
+	for _, r := range redact {
+		if strings.EqualFold(r, header) {
+			return redactedValue
+		}
+	}
+	return value
+}
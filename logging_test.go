@@ -0,0 +1,113 @@
+package htmxheaders_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestLogHTMX(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w, hh.Retarget("#errors"), hh.Reswap(hh.SwapOuterHTML))
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte("<p>Invalid</p>"))
+
+		// Headers set after the response has been written are not sent, so are not logged.
+		w.Header().Set("HX-Refresh", "true")
+	})
+
+	r := httptest.NewRequest("POST", "/contact", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Target", "contact-form")
+	r.Header.Set("HX-Prompt", "my secret answer")
+	hh.LogHTMX(handler, hh.LogOptions{Logger: logger}).ServeHTTP(httptest.NewRecorder(), r)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "htmx exchange", record["msg"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, "/contact", record["path"])
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), record["status"])
+	assert.Equal(t, float64(14), record["size"])
+	assert.Contains(t, record, "latency")
+	assert.Equal(t, map[string]any{
+		"htmx":    true,
+		"boosted": false,
+		"target":  "contact-form",
+		"prompt":  "[REDACTED]",
+	}, record["request"])
+	assert.Equal(t, map[string]any{
+		"Retarget": "#errors",
+		"Reswap":   "outerHTML",
+	}, record["decorators"])
+}
+
+func TestLogHTMXRecordsImplicitStatus(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w, hh.Trigger(hh.TriggerAfterSwap, "saved"))
+		require.NoError(t, err)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	hh.LogHTMX(handler, hh.LogOptions{Logger: logger, Redact: []string{}}).ServeHTTP(httptest.NewRecorder(), r)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, float64(http.StatusOK), record["status"])
+	assert.Equal(t, map[string]any{"TriggerAfterSwap": "saved"}, record["decorators"])
+}
+
+func TestLogHTMXSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	called := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	opts := hh.LogOptions{Logger: logger, Sample: hh.SampleRate(0)}
+	hh.LogHTMX(handler, opts).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.True(t, called)
+	assert.Empty(t, buf.String())
+}
+
+func TestLogHTMXLevelPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	opts := hh.LogOptions{
+		Logger: logger,
+		Level: func(r *http.Request, status int) slog.Level {
+			if !hh.IsHTMXRequest(r) {
+				return slog.LevelDebug
+			}
+			return slog.LevelInfo
+		},
+	}
+	hh.LogHTMX(okHandler, opts).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Empty(t, buf.String())
+}
+
+func TestLevelForStatus(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, hh.LevelForStatus(nil, http.StatusOK))
+	assert.Equal(t, slog.LevelInfo, hh.LevelForStatus(nil, http.StatusFound))
+	assert.Equal(t, slog.LevelWarn, hh.LevelForStatus(nil, http.StatusNotFound))
+	assert.Equal(t, slog.LevelError, hh.LevelForStatus(nil, http.StatusBadGateway))
+}
@@ -40,15 +40,15 @@ type DecoratorFunction func(w http.ResponseWriter) error
 //
 //	It's the responsibility of the caller to ensure that the provided response writer 'w' is not nil.
 //	Passing a nil response writer will result in a panic.
+//
+//	Hooks registered with RegisterHook are called around each decorator.
 func SetResponseHeaders(w http.ResponseWriter, decorators ...DecoratorFunction) error {
-	for _, decorator := range decorators {
-		if err := decorator(w); err != nil {
-			return err
-		}
-	}
-	return nil
+	return defaultBuilder.SetResponseHeaders(w, decorators...)
 }
 
+// defaultBuilder is used by SetResponseHeaders, and only calls the hooks registered with RegisterHook.
+var defaultBuilder = NewBuilder()
+
 func AddCustomHeader(key, value string) DecoratorFunction {
 	return func(w http.ResponseWriter) error {
 		w.Header().Set(key, value)
@@ -0,0 +1,442 @@
+package htmxheaders
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// hxTagHeaders maps the kinds of hx struct tag to the header they set.
+var hxTagHeaders = map[string]string{
+	"retarget":    "HX-Retarget",
+	"reswap":      "HX-Reswap",
+	"reselect":    "HX-Reselect",
+	"push-url":    "HX-Push-Url",
+	"replace-url": "HX-Replace-Url",
+	"redirect":    "HX-Redirect",
+	"refresh":     "HX-Refresh",
+	"location":    "HX-Location",
+	"trigger":     "",
+}
+
+var (
+	swapType            = reflect.TypeOf(Swap(0))
+	locationType        = reflect.TypeOf(LocationContextWithPath{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// hxField describes a struct field with an hx tag.
+type hxField struct {
+	index  []int
+	name   string       // the name of the Go field
+	kind   string       // the kind of header, such as "retarget" or "trigger"
+	header string       // the header set, for kinds other than "trigger"
+	when   TriggerDelay // when a trigger event is dispatched
+	event  string       // the name of a trigger event
+}
+
+// hxFieldCache caches the hx fields of each struct type, or the error describing an invalid tag.
+var hxFieldCache sync.Map // map[reflect.Type]hxFieldsResult
+
+type hxFieldsResult struct {
+	fields []hxField
+	err    error
+}
+
+// Marshal sets the response headers described by the hx struct tags of v, which must be a struct or a
+// pointer to a struct. Each tagged field is applied through SetResponseHeaders, so any registered hooks
+// are called. Fields holding their zero value, and nil pointers, are omitted. As the zero Swap is SwapInnerHTML,
+// a reswap field of type *Swap or *SwapSpec is needed to set HX-Reswap to innerHTML.
+//
+// The tag names the header set by the field, followed by any options:
+//
+//	retarget     string, validated as by Retarget
+//	reswap       Swap, SwapSpec or string
+//	reselect     string
+//	push-url     string, "false" prevents the history being updated
+//	replace-url  string, "false" prevents the location being updated
+//	redirect     string
+//	refresh      bool
+//	location     string or LocationContextWithPath
+//	trigger      any value, triggering an event with the value as its detail, or a bool triggering an event without one.
+//	             The options after-swap and after-settle set when the event is dispatched, and name=... sets
+//	             the name of the event, which otherwise is the field name with its first letter in lower case.
+//
+// Other string and bool fields, and types implementing encoding.TextMarshaler, are accepted for all
+// headers other than HX-Trigger. The events of trigger fields with the same timing are combined into one header.
+//
+// The encoding of each struct type is cached, so the tags are only parsed once.
+//
+// Example usage:
+//
+//	type SaveResult struct {
+//	    Target string      `hx:"retarget"`
+//	    Swap   hh.SwapSpec `hx:"reswap"`
+//	    Saved  SavedEvent  `hx:"trigger,after-swap,name=saved"`
+//	}
+//
+//	err := hh.Marshal(w, SaveResult{Target: "#rows", Swap: hh.SwapSpec{Swap: hh.SwapBeforeEnd}, Saved: saved})
+//	if err != nil {
+//	    // Handle error
+//	}
+func Marshal(w http.ResponseWriter, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return errors.New("cannot marshal a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot marshal non-struct type %s", rv.Type())
+	}
+
+	fields, err := cachedHXFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	var decorators []DecoratorFunction
+	var events [3][]TriggerEvent
+	var eventsAt [3]int
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || fv.IsZero() {
+			continue
+		}
+		for fv.Kind() == reflect.Pointer {
+			fv = fv.Elem()
+		}
+
+		if f.kind == "trigger" {
+			event := TriggerEvent{Name: f.event}
+			if fv.Kind() != reflect.Bool {
+				event.Detail = fv.Interface()
+			}
+			if events[f.when] == nil {
+				eventsAt[f.when] = len(decorators)
+				decorators = append(decorators, nil)
+			}
+			events[f.when] = append(events[f.when], event)
+			continue
+		}
+
+		decorator, err := fieldDecorator(f, fv)
+		if err != nil {
+			return err
+		}
+		decorators = append(decorators, decorator)
+	}
+
+	for when, whenEvents := range events {
+		if whenEvents != nil {
+			decorators[eventsAt[when]] = triggerDecorator(TriggerDelay(when), whenEvents)
+		}
+	}
+
+	return SetResponseHeaders(w, decorators...)
+}
+
+// Unmarshal sets the hx tagged fields of the struct pointed to by v from the response headers h,
+// reversing Marshal. Fields whose header is not set are left unchanged.
+//
+// Example usage:
+//
+//	var result SaveResult
+//	err := hh.Unmarshal(w.Result().Header, &result)
+//	if err != nil {
+//	    // Handle error
+//	}
+func Unmarshal(h http.Header, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("cannot unmarshal into a nil or non-pointer value")
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into non-struct type %s", rv.Type())
+	}
+
+	fields, err := cachedHXFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	var events [3][]TriggerEvent
+	for when := range events {
+		events[when], err = DecodeTriggerHeader(h.Get(TriggerDelay(when).String()))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		if f.kind == "trigger" {
+			for _, event := range events[f.when] {
+				if event.Name == f.event {
+					if err := setTriggerField(rv, f, event); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+
+		value := h.Get(f.header)
+		if value == "" {
+			continue
+		}
+		if err := setHeaderField(rv, f, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cachedHXFields returns the hx fields of the struct type, parsing its tags on first use.
+func cachedHXFields(t reflect.Type) ([]hxField, error) {
+	if cached, ok := hxFieldCache.Load(t); ok {
+		result := cached.(hxFieldsResult)
+		return result.fields, result.err
+	}
+
+	fields, err := parseHXFields(t, nil)
+	cached, _ := hxFieldCache.LoadOrStore(t, hxFieldsResult{fields: fields, err: err})
+	result := cached.(hxFieldsResult)
+	return result.fields, result.err
+}
+
+// parseHXFields returns the hx fields of the struct type, including those of untagged embedded structs.
+func parseHXFields(t reflect.Type, index []int) ([]hxField, error) {
+	var fields []hxField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		tag, tagged := sf.Tag.Lookup("hx")
+		if !tagged || tag == "-" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if !tagged && sf.Anonymous && embedded.Kind() == reflect.Struct {
+				nested, err := parseHXFields(embedded, fieldIndex)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nested...)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("field %s.%s has an hx tag but is not exported", t.Name(), sf.Name)
+		}
+
+		f, err := parseHXTag(sf, tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hx tag on field %s.%s: %w", t.Name(), sf.Name, err)
+		}
+		f.index = fieldIndex
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// parseHXTag parses the hx tag of a field, checking that the field has a type supported by its header.
+func parseHXTag(sf reflect.StructField, tag string) (hxField, error) {
+	kind, options, _ := strings.Cut(tag, ",")
+	header, ok := hxTagHeaders[kind]
+	if !ok {
+		return hxField{}, fmt.Errorf("unknown header %q", kind)
+	}
+
+	f := hxField{name: sf.Name, kind: kind, header: header}
+	if kind != "trigger" {
+		if options != "" {
+			return hxField{}, fmt.Errorf("unknown option %q", options)
+		}
+		if !supportsHeaderType(kind, indirectType(sf.Type)) {
+			return hxField{}, fmt.Errorf("type %s is not supported by %q", sf.Type, kind)
+		}
+		return f, nil
+	}
+
+	f.event = strings.ToLower(sf.Name[:1]) + sf.Name[1:]
+	if options == "" {
+		return f, nil
+	}
+	for _, option := range strings.Split(options, ",") {
+		switch {
+		case option == "after-swap":
+			f.when = TriggerAfterSwap
+		case option == "after-settle":
+			f.when = TriggerAfterSettle
+		case strings.HasPrefix(option, "name="):
+			f.event = strings.TrimPrefix(option, "name=")
+			if f.event == "" {
+				return hxField{}, errors.New("empty trigger event name")
+			}
+		default:
+			return hxField{}, fmt.Errorf("unknown option %q", option)
+		}
+	}
+	return f, nil
+}
+
+func supportsHeaderType(kind string, t reflect.Type) bool {
+	switch {
+	case kind == "reswap" && t == swapType:
+		return true
+	case kind == "location" && t == locationType:
+		return true
+	case kind == "retarget" && t.Kind() != reflect.String:
+		return false
+	}
+	if t.Kind() == reflect.String || t.Kind() == reflect.Bool {
+		return true
+	}
+	return t.Implements(textMarshalerType) && reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldByIndex returns the field at the index, reporting false if it is within a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldDecorator returns the decorator setting the header of the field to its value.
+func fieldDecorator(f hxField, v reflect.Value) (DecoratorFunction, error) {
+	switch {
+	case v.Type() == swapType:
+		return Reswap(v.Interface().(Swap)), nil
+	case v.Type() == locationType:
+		location := v.Interface().(LocationContextWithPath)
+		return LocationWithContext(location.Path, location.LocationContext), nil
+	case f.kind == "retarget":
+		return Retarget(v.String()), nil
+	}
+
+	var value string
+	switch v.Kind() {
+	case reflect.String:
+		value = v.String()
+	case reflect.Bool:
+		value = strconv.FormatBool(v.Bool())
+	default:
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding field %s: %w", f.name, err)
+		}
+		value = string(text)
+	}
+	return AddCustomHeader(f.header, value), nil
+}
+
+// triggerDecorator triggers the events, as a list of names when none of them have details.
+func triggerDecorator(when TriggerDelay, events []TriggerEvent) DecoratorFunction {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		if event.Detail != nil {
+			return TriggerWithDetail(when, events...)
+		}
+		names = append(names, event.Name)
+	}
+	return Trigger(when, names...)
+}
+
+// settableField returns the field at the index, allocating any nil embedded pointers and the field itself if it is a pointer.
+func settableField(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// setHeaderField sets the field from the value of its header.
+func setHeaderField(rv reflect.Value, f hxField, value string) error {
+	fv := settableField(rv, f.index)
+
+	var err error
+	switch {
+	case fv.Type() == swapType:
+		method, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+		var swap Swap
+		swap, err = SwapFromString(method)
+		fv.Set(reflect.ValueOf(swap))
+	case fv.Type() == locationType:
+		location := LocationContextWithPath{Path: value}
+		if strings.HasPrefix(value, "{") {
+			location = LocationContextWithPath{}
+			err = json.Unmarshal([]byte(value), &location)
+		}
+		fv.Set(reflect.ValueOf(location))
+	case fv.Kind() == reflect.String:
+		fv.SetString(value)
+	case fv.Kind() == reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		fv.SetBool(b)
+	default:
+		err = fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if err != nil {
+		return fmt.Errorf("error decoding %s into field %s: %w", f.header, f.name, err)
+	}
+	return nil
+}
+
+// setTriggerField sets the field from the detail of its event, or to true for a bool field.
+func setTriggerField(rv reflect.Value, f hxField, event TriggerEvent) error {
+	fv := settableField(rv, f.index)
+	if fv.Kind() == reflect.Bool {
+		fv.SetBool(true)
+		return nil
+	}
+	if event.Detail == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(event.Detail)
+	if err == nil {
+		err = json.Unmarshal(data, fv.Addr().Interface())
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding trigger event %q into field %s: %w", event.Name, f.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package htmxheaders_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+type savedEvent struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type saveResult struct {
+	Target  string      `hx:"retarget"`
+	Swap    hh.SwapSpec `hx:"reswap"`
+	PushURL string      `hx:"push-url"`
+	Saved   *savedEvent `hx:"trigger,after-swap,name=saved"`
+	Closed  bool        `hx:"trigger,after-swap,name=modal:close"`
+	Refresh bool        `hx:"trigger"`
+	Note    string
+}
+
+func TestMarshal(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := hh.Marshal(w, saveResult{
+		Target:  "#rows",
+		Swap:    hh.SwapSpec{Swap: hh.SwapBeforeEnd, Scroll: "bottom"},
+		Saved:   &savedEvent{ID: 7, Name: "Widget"},
+		Closed:  true,
+		Refresh: true,
+		Note:    "ignored",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "#rows", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "beforeend scroll:bottom", w.Header().Get("HX-Reswap"))
+	assert.Equal(t, `{"saved":{"id":7,"name":"Widget"},"modal:close":null}`, w.Header().Get("HX-Trigger-After-Swap"))
+	assert.Equal(t, "refresh", w.Header().Get("HX-Trigger"))
+	assert.Empty(t, w.Header().Get("HX-Push-Url"))
+}
+
+func TestMarshalSupportedTypes(t *testing.T) {
+	type embedded struct {
+		Refresh bool `hx:"refresh"`
+	}
+	type response struct {
+		embedded
+		Swap     *hh.Swap                   `hx:"reswap"`
+		Location hh.LocationContextWithPath `hx:"location"`
+	}
+	swap := hh.SwapInnerHTML
+	w := httptest.NewRecorder()
+
+	err := hh.Marshal(w, &response{
+		embedded: embedded{Refresh: true},
+		Swap:     &swap,
+		Location: hh.LocationContextWithPath{Path: "/orders", LocationContext: hh.LocationContext{Target: "#main"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "true", w.Header().Get("HX-Refresh"))
+	assert.Equal(t, "innerHTML", w.Header().Get("HX-Reswap"))
+	assert.Equal(t, `{"target":"#main","path":"/orders"}`, w.Header().Get("HX-Location"))
+}
+
+func TestMarshalOmitsZeroSwap(t *testing.T) {
+	type response struct {
+		Target string      `hx:"retarget"`
+		Swap   hh.Swap     `hx:"reswap"`
+		Spec   hh.SwapSpec `hx:"reswap"`
+	}
+
+	w := httptest.NewRecorder()
+	err := hh.Marshal(w, response{Target: "#rows"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "#rows", w.Header().Get("HX-Retarget"))
+	assert.Empty(t, w.Header().Values("HX-Reswap"))
+}
+
+func TestMarshalSetsInnerHTMLFromPointer(t *testing.T) {
+	type response struct {
+		Swap *hh.Swap `hx:"reswap"`
+	}
+
+	swap := hh.SwapInnerHTML
+	w := httptest.NewRecorder()
+	err := hh.Marshal(w, response{Swap: &swap})
+
+	require.NoError(t, err)
+	assert.Equal(t, "innerHTML", w.Header().Get("HX-Reswap"))
+}
+
+func TestMarshalValidatesRetarget(t *testing.T) {
+	err := hh.Marshal(httptest.NewRecorder(), saveResult{Target: "#rows[data-id"})
+
+	assert.Error(t, err)
+}
+
+func TestMarshalInvalidTags(t *testing.T) {
+	tests := []any{
+		struct {
+			Target string `hx:"target"`
+		}{},
+		struct {
+			Target int `hx:"retarget"`
+		}{},
+		struct {
+			Saved bool `hx:"trigger,eventually"`
+		}{},
+		struct {
+			Refresh bool `hx:"refresh,after-swap"`
+		}{},
+		"not a struct",
+	}
+
+	for _, test := range tests {
+		assert.Error(t, hh.Marshal(httptest.NewRecorder(), test), "%#v", test)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	w := httptest.NewRecorder()
+	expected := saveResult{
+		Target:  "#rows",
+		Swap:    hh.SwapSpec{Swap: hh.SwapOuterHTML, Transition: true},
+		PushURL: "false",
+		Saved:   &savedEvent{ID: 7, Name: "Widget"},
+		Closed:  true,
+	}
+	require.NoError(t, hh.Marshal(w, expected))
+
+	var result saveResult
+	err := hh.Unmarshal(w.Header(), &result)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var result saveResult
+	assert.Error(t, hh.Unmarshal(httptest.NewRecorder().Header(), result))
+
+	w := httptest.NewRecorder()
+	w.Header().Set("HX-Reswap", "sideways")
+	assert.Error(t, hh.Unmarshal(w.Header(), &result))
+}
@@ -0,0 +1,161 @@
+package htmxheaders
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kinds of request counted by Metrics.
+const (
+	RequestKindPage    = "page"    // requests not made by htmx
+	RequestKindHTMX    = "htmx"    // requests made by htmx, other than boosted requests
+	RequestKindBoosted = "boosted" // requests made by htmx via an element using hx-boost
+)
+
+// Metrics counts htmx traffic: requests by route and kind, the events triggered by the HX-Trigger headers
+// and the decorators used in responses. The counts are exposed through expvar with Publish, and in the
+// Prometheus text format with PrometheusHandler.
+type Metrics struct {
+	mu         sync.Mutex
+	requests   map[[2]string]uint64 // route, kind
+	triggers   map[[2]string]uint64 // event, trigger header
+	decorators map[[2]string]uint64 // route, decorator
+}
+
+// NewMetrics creates an empty Metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:   map[[2]string]uint64{},
+		triggers:   map[[2]string]uint64{},
+		decorators: map[[2]string]uint64{},
+	}
+}
+
+// Handler returns a http.Handler that counts the requests to next under the given route name,
+// along with the events triggered and decorators used in the responses.
+//
+// Example usage:
+//
+//	metrics := hh.NewMetrics()
+//	metrics.Publish("htmx")
+//	http.Handle("/orders", metrics.Handler("/orders", ordersHandler))
+//	http.Handle("/metrics", metrics.PrometheusHandler())
+func (m *Metrics) Handler(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := newStatusWriter(w)
+		next.ServeHTTP(sw, r)
+		sw.finish()
+		m.record(route, r, sw.header)
+	})
+}
+
+func (m *Metrics) record(route string, r *http.Request, h http.Header) {
+	kind := RequestKindPage
+	if IsBoostedRequest(r) {
+		kind = RequestKindBoosted
+	} else if IsHTMXRequest(r) {
+		kind = RequestKindHTMX
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[[2]string{route, kind}]++
+	for _, name := range hxHeaderNames(h) {
+		m.decorators[[2]string{route, DecoratorName(name)}]++
+	}
+
+	for _, when := range []TriggerDelay{TriggerImmediately, TriggerAfterSettle, TriggerAfterSwap} {
+		events, err := DecodeTriggerHeader(h.Get(when.String()))
+		if err != nil {
+			continue
+		}
+		for _, event := range events {
+			m.triggers[[2]string{event.Name, when.String()}]++
+		}
+	}
+}
+
+// Publish exposes the counts through expvar under the given name, as a JSON object of the
+// requests, triggers and decorators counted. Like expvar.Publish, it panics if the name is already in use.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return m.expvarValue() }))
+}
+
+func (m *Metrics) expvarValue() map[string]map[string]map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nest := func(counts map[[2]string]uint64) map[string]map[string]uint64 {
+		nested := map[string]map[string]uint64{}
+		for key, count := range counts {
+			if nested[key[0]] == nil {
+				nested[key[0]] = map[string]uint64{}
+			}
+			nested[key[0]][key[1]] = count
+		}
+		return nested
+	}
+
+	return map[string]map[string]map[string]uint64{
+		"requests":   nest(m.requests),
+		"triggers":   nest(m.triggers),
+		"decorators": nest(m.decorators),
+	}
+}
+
+// PrometheusHandler returns a http.Handler serving the counts in the Prometheus text exposition format.
+func (m *Metrics) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes the counts to w in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+	writePrometheusCounter(&sb, "htmx_requests_total", "Requests by route and kind of request.",
+		[2]string{"route", "kind"}, m.requests)
+	writePrometheusCounter(&sb, "htmx_triggers_total", "Events triggered by HX-Trigger response headers.",
+		[2]string{"event", "header"}, m.triggers)
+	writePrometheusCounter(&sb, "htmx_decorators_total", "HX response headers set, by route and decorator.",
+		[2]string{"route", "decorator"}, m.decorators)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writePrometheusCounter(sb *strings.Builder, name, help string, labels [2]string, counts map[[2]string]uint64) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+
+	keys := make([][2]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	for _, key := range keys {
+		fmt.Fprintf(sb, "%s{%s=\"%s\",%s=\"%s\"} %d\n",
+			name, labels[0], escapePrometheusLabel(key[0]), labels[1], escapePrometheusLabel(key[1]), counts[key])
+	}
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapePrometheusLabel(value string) string {
+	return prometheusLabelEscaper.Replace(value)
+}
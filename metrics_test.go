@@ -0,0 +1,90 @@
+package htmxheaders_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func sendMetricsRequests(t *testing.T, metrics *hh.Metrics) {
+	t.Helper()
+
+	orders := metrics.Handler("/orders", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := hh.SetResponseHeaders(w,
+			hh.Trigger(hh.TriggerImmediately, "orderSaved", "closeModal"),
+			hh.TriggerWithDetail(hh.TriggerAfterSwap, hh.TriggerEvent{Name: "toast", Detail: "Saved"}),
+		)
+		require.NoError(t, err)
+	}))
+	login := metrics.Handler(`/login"`, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, hh.SetResponseHeaders(w, hh.Redirect("/")))
+	}))
+
+	htmx := httptest.NewRequest("POST", "/orders", nil)
+	htmx.Header.Set("HX-Request", "true")
+	boosted := httptest.NewRequest("GET", "/orders", nil)
+	boosted.Header.Set("HX-Request", "true")
+	boosted.Header.Set("HX-Boosted", "true")
+
+	orders.ServeHTTP(httptest.NewRecorder(), htmx)
+	orders.ServeHTTP(httptest.NewRecorder(), htmx)
+	orders.ServeHTTP(httptest.NewRecorder(), boosted)
+	login.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/login", nil))
+}
+
+func TestMetricsPrometheusHandler(t *testing.T) {
+	metrics := hh.NewMetrics()
+	sendMetricsRequests(t, metrics)
+
+	w := httptest.NewRecorder()
+	metrics.PrometheusHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := strings.Join([]string{
+		"# HELP htmx_requests_total Requests by route and kind of request.",
+		"# TYPE htmx_requests_total counter",
+		`htmx_requests_total{route="/login\"",kind="page"} 1`,
+		`htmx_requests_total{route="/orders",kind="boosted"} 1`,
+		`htmx_requests_total{route="/orders",kind="htmx"} 2`,
+		"# HELP htmx_triggers_total Events triggered by HX-Trigger response headers.",
+		"# TYPE htmx_triggers_total counter",
+		`htmx_triggers_total{event="closeModal",header="HX-Trigger"} 3`,
+		`htmx_triggers_total{event="orderSaved",header="HX-Trigger"} 3`,
+		`htmx_triggers_total{event="toast",header="HX-Trigger-After-Swap"} 3`,
+		"# HELP htmx_decorators_total HX response headers set, by route and decorator.",
+		"# TYPE htmx_decorators_total counter",
+		`htmx_decorators_total{route="/login\"",decorator="Redirect"} 1`,
+		`htmx_decorators_total{route="/orders",decorator="Trigger"} 3`,
+		`htmx_decorators_total{route="/orders",decorator="TriggerAfterSwap"} 3`,
+	}, "\n") + "\n"
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, expected, w.Body.String())
+}
+
+// publishCount makes the expvar name published by each run of TestMetricsPublish unique, as expvar panics
+// when a name is published twice, such as with go test -count=2.
+var publishCount atomic.Int64
+
+func TestMetricsPublish(t *testing.T) {
+	metrics := hh.NewMetrics()
+	sendMetricsRequests(t, metrics)
+	name := fmt.Sprintf("htmx_metrics_test_%d", publishCount.Add(1))
+	metrics.Publish(name)
+
+	var value map[string]map[string]map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &value))
+
+	assert.Equal(t, float64(2), value["requests"]["/orders"]["htmx"])
+	assert.Equal(t, float64(1), value["requests"]["/orders"]["boosted"])
+	assert.Equal(t, float64(3), value["triggers"]["toast"]["HX-Trigger-After-Swap"])
+	assert.Equal(t, float64(1), value["decorators"][`/login"`]["Redirect"])
+}
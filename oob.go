@@ -0,0 +1,50 @@
+package htmxheaders
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// OOBFragment represents a piece of HTML swapped into the page out of band, that is, into an element
+// other than the target of the request. Out of band fragments can be included in any htmx response,
+// and are the only way content is swapped by the htmx WebSocket extension.
+// https://htmx.org/attributes/hx-swap-oob/
+type OOBFragment struct {
+	Target string // a CSS selector for the element being swapped
+	Swap   Swap   // how the content will be swapped in relative to the target
+	HTML   string // the content being swapped in
+}
+
+// String returns the fragment wrapped in an element with the hx-swap-oob attribute.
+//
+// Note:
+//
+//	For all swaps other than SwapOuterHTML, the wrapping element is discarded by htmx and only the
+//	content is swapped in. For SwapOuterHTML, the wrapping element replaces the target, so the HTML
+//	should be the content of the element rather than the element itself.
+func (f OOBFragment) String() string {
+	var sb strings.Builder
+	sb.WriteString(`<div hx-swap-oob="`)
+	sb.WriteString(html.EscapeString(f.Swap.String() + ":" + f.Target))
+	sb.WriteString(`">`)
+	sb.WriteString(f.HTML)
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+// Render writes the fragment to w.
+func (f OOBFragment) Render(w io.Writer) error {
+	_, err := io.WriteString(w, f.String())
+	return err
+}
+
+// RenderOOB writes each of the fragments to w.
+func RenderOOB(w io.Writer, fragments ...OOBFragment) error {
+	for _, f := range fragments {
+		if err := f.Render(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
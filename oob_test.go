@@ -0,0 +1,29 @@
+package htmxheaders_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestOOBFragmentString(t *testing.T) {
+	fragment := hh.OOBFragment{Target: `#messages li[data-x="y"]`, Swap: hh.SwapBeforeEnd, HTML: "<li>Hello</li>"}
+
+	expected := `<div hx-swap-oob="beforeend:#messages li[data-x=&#34;y&#34;]"><li>Hello</li></div>`
+	assert.Equal(t, expected, fragment.String())
+}
+
+func TestRenderOOB(t *testing.T) {
+	var sb strings.Builder
+	err := hh.RenderOOB(&sb,
+		hh.OOBFragment{Target: "#count", HTML: "3"},
+		hh.OOBFragment{Target: "#list", Swap: hh.SwapAfterBegin, HTML: "<li>3</li>"},
+	)
+	require.NoError(t, err)
+
+	expected := `<div hx-swap-oob="innerHTML:#count">3</div><div hx-swap-oob="afterbegin:#list"><li>3</li></div>`
+	assert.Equal(t, expected, sb.String())
+}
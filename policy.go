@@ -0,0 +1,410 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownPolicy is returned when applying a policy that has not been loaded.
+var ErrUnknownPolicy = errors.New("unknown policy")
+
+// Policy describes the headers set for a named outcome, such as a validation error.
+// Policies are usually loaded from a YAML or JSON file with LoadPolicies:
+//
+//	validationError:
+//	  retarget: "#errors"
+//	  reswap: outerHTML
+//	  trigger: shake
+//	saved:
+//	  trigger-after-swap:
+//	    - name: saved
+//	      detail: {message: Saved}
+//	    - closeModal
+type Policy struct {
+	Retarget           string       `json:"retarget" yaml:"retarget"`
+	Reswap             string       `json:"reswap" yaml:"reswap"` // an hx-swap value, such as "outerHTML swap:500ms"
+	Reselect           string       `json:"reselect" yaml:"reselect"`
+	PushURL            string       `json:"push-url" yaml:"push-url"`
+	ReplaceURL         string       `json:"replace-url" yaml:"replace-url"`
+	Redirect           string       `json:"redirect" yaml:"redirect"`
+	Refresh            bool         `json:"refresh" yaml:"refresh"`
+	Location           string       `json:"location" yaml:"location"`
+	Trigger            PolicyEvents `json:"trigger" yaml:"trigger"`
+	TriggerAfterSwap   PolicyEvents `json:"trigger-after-swap" yaml:"trigger-after-swap"`
+	TriggerAfterSettle PolicyEvents `json:"trigger-after-settle" yaml:"trigger-after-settle"`
+}
+
+// PolicyEvents are the events triggered by a Policy. In a policy file they are given as a comma separated
+// string of event names, or a list whose items are either an event name or an object with a name, and
+// optionally a detail and target.
+type PolicyEvents []TriggerEvent
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *PolicyEvents) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return e.set(v)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *PolicyEvents) UnmarshalYAML(value *yaml.Node) error {
+	var v any
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	return e.set(v)
+}
+
+// set sets the events from a string or list decoded from a policy file.
+func (e *PolicyEvents) set(v any) error {
+	switch v := v.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case string:
+		events, err := DecodeTriggerHeader(v)
+		*e = events
+		return err
+	case []any:
+		events := make(PolicyEvents, 0, len(v))
+		for _, item := range v {
+			event, err := policyEvent(item)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		*e = events
+		return nil
+	default:
+		return fmt.Errorf("trigger must be a string or a list of events, not %T", v)
+	}
+}
+
+// policyEvent returns the event described by an item in a list of PolicyEvents.
+func policyEvent(item any) (TriggerEvent, error) {
+	switch item := item.(type) {
+	case string:
+		return TriggerEvent{Name: item}, nil
+	case map[string]any:
+		var event TriggerEvent
+		for key, value := range item {
+			var ok bool
+			switch key {
+			case "name":
+				event.Name, ok = value.(string)
+			case "target":
+				event.Target, ok = value.(string)
+			case "detail":
+				event.Detail, ok = value, true
+			default:
+				return TriggerEvent{}, fmt.Errorf("unknown trigger event field %q", key)
+			}
+			if !ok {
+				return TriggerEvent{}, fmt.Errorf("trigger event field %q must be a string", key)
+			}
+		}
+		return event, nil
+	default:
+		return TriggerEvent{}, fmt.Errorf("trigger event must be a name or an object, not %T", item)
+	}
+}
+
+// Decorators returns the decorators applying the policy, in a fixed order.
+func (p Policy) Decorators() []DecoratorFunction {
+	var decorators []DecoratorFunction
+	add := func(set bool, decorator func() DecoratorFunction) {
+		if set {
+			decorators = append(decorators, decorator())
+		}
+	}
+
+	add(p.Retarget != "", func() DecoratorFunction { return Retarget(p.Retarget) })
+	add(p.Reswap != "", func() DecoratorFunction { return reswapPolicy(p.Reswap) })
+	add(p.Reselect != "", func() DecoratorFunction { return Reselect(p.Reselect) })
+	add(p.PushURL != "", func() DecoratorFunction { return PushURL(p.PushURL) })
+	add(p.ReplaceURL != "", func() DecoratorFunction { return ReplaceURL(p.ReplaceURL) })
+	add(p.Redirect != "", func() DecoratorFunction { return Redirect(p.Redirect) })
+	add(p.Refresh, Refresh)
+	add(p.Location != "", func() DecoratorFunction { return Location(p.Location) })
+	add(len(p.Trigger) > 0, func() DecoratorFunction { return triggerDecorator(TriggerImmediately, p.Trigger) })
+	add(len(p.TriggerAfterSwap) > 0, func() DecoratorFunction { return triggerDecorator(TriggerAfterSwap, p.TriggerAfterSwap) })
+	add(len(p.TriggerAfterSettle) > 0, func() DecoratorFunction { return triggerDecorator(TriggerAfterSettle, p.TriggerAfterSettle) })
+	return decorators
+}
+
+// reswapPolicy sets HX-Reswap to the hx-swap value, returning an error if it is invalid.
+func reswapPolicy(value string) DecoratorFunction {
+	return func(w http.ResponseWriter) error {
+		spec, err := ParseSwapSpec(value)
+		if err != nil {
+			return fmt.Errorf("invalid HX-Reswap: %w", err)
+		}
+		return ReswapSpec(spec)(w)
+	}
+}
+
+// Validate returns an error if the policy sets no headers, or any of its headers could not be set.
+func (p Policy) Validate() error {
+	decorators := p.Decorators()
+	if len(decorators) == 0 {
+		return errors.New("policy sets no headers")
+	}
+
+	dry := &headerWriter{header: http.Header{}}
+	for _, decorator := range decorators {
+		if err := decorator(dry); err != nil {
+			return err
+		}
+	}
+	for _, events := range []PolicyEvents{p.Trigger, p.TriggerAfterSwap, p.TriggerAfterSettle} {
+		for _, event := range events {
+			if strings.TrimSpace(event.Name) == "" {
+				return errors.New("trigger event name must not be empty")
+			}
+		}
+		if _, err := encodeTriggerEvents(events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Policies holds a set of named policies, optionally loaded from a file which can be reloaded when it changes.
+// Policies is safe for concurrent use.
+type Policies struct {
+	path string
+
+	mu       sync.RWMutex
+	policies map[string]Policy
+	modTime  time.Time
+	size     int64
+}
+
+// NewPolicies returns Policies holding the given policies, returning an error if any of them are invalid.
+func NewPolicies(policies map[string]Policy) (*Policies, error) {
+	if err := validatePolicies(policies); err != nil {
+		return nil, err
+	}
+	return &Policies{policies: policies}, nil
+}
+
+// LoadPolicies loads policies from a YAML file, with a .yaml or .yml extension, or a JSON file, with a .json extension.
+// The file maps the name of each policy to its Policy. Unknown fields and invalid policies are reported as errors,
+// so that mistakes are found when the file is loaded rather than when a policy is applied.
+//
+// Example usage:
+//
+//	policies, err := hh.LoadPolicies("policies.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	hh.SetPolicies(policies)
+//	go policies.Watch(ctx, 5*time.Second, func(err error) { slog.Error("reloading policies", "error", err) })
+func LoadPolicies(path string) (*Policies, error) {
+	p := &Policies{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload loads the policies again from their file. If the file cannot be read or is invalid, an error is
+// returned and the existing policies are kept.
+func (p *Policies) Reload() error {
+	if p.path == "" {
+		return errors.New("policies were not loaded from a file")
+	}
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("error loading policies: %w", err)
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("error loading policies: %w", err)
+	}
+
+	policies, err := parsePolicies(data, filepath.Ext(p.path))
+	if err != nil {
+		return fmt.Errorf("error loading policies from %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	p.policies = policies
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	p.mu.Unlock()
+	return nil
+}
+
+// Watch checks the file the policies were loaded from every interval, reloading the policies when it changes,
+// until the context is done. Errors reloading the policies are passed to onError, which may be nil, and the
+// previous policies are kept. Watch returns immediately if the policies were not loaded from a file.
+func (p *Policies) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if p.path == "" {
+		return
+	}
+
+	// Changes are tracked separately from the loaded policies, so an invalid file is only reported once.
+	p.mu.RLock()
+	modTime, size := p.modTime, p.size
+	p.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(p.path)
+		if err == nil {
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			modTime, size = info.ModTime(), info.Size()
+			err = p.Reload()
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Names returns the names of the policies, in alphabetical order.
+func (p *Policies) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.policies))
+	for name := range p.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named policy, reporting whether it exists.
+func (p *Policies) Lookup(name string) (Policy, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	policy, ok := p.policies[name]
+	return policy, ok
+}
+
+// Apply sets the headers of the named policy through SetResponseHeaders.
+// An error wrapping ErrUnknownPolicy is returned if there is no policy with the name.
+func (p *Policies) Apply(w http.ResponseWriter, name string) error {
+	policy, ok := p.Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownPolicy, name)
+	}
+	return SetResponseHeaders(w, policy.Decorators()...)
+}
+
+var defaultPolicies struct {
+	mu       sync.RWMutex
+	policies *Policies
+}
+
+// SetPolicies sets the policies used by ApplyPolicy.
+func SetPolicies(p *Policies) {
+	defaultPolicies.mu.Lock()
+	defaultPolicies.policies = p
+	defaultPolicies.mu.Unlock()
+}
+
+// ApplyPolicy sets the headers of the named policy from the policies set with SetPolicies.
+// An error wrapping ErrUnknownPolicy is returned if there is no policy with the name, or no policies have been set.
+//
+// Example usage:
+//
+//	if err := form.Validate(); err != nil {
+//	    _ = hh.ApplyPolicy(w, "validationError")
+//	    renderErrors(w, err)
+//	    return
+//	}
+func ApplyPolicy(w http.ResponseWriter, name string) error {
+	defaultPolicies.mu.RLock()
+	p := defaultPolicies.policies
+	defaultPolicies.mu.RUnlock()
+
+	if p == nil {
+		return fmt.Errorf("%w: %q, no policies have been set", ErrUnknownPolicy, name)
+	}
+	return p.Apply(w, name)
+}
+
+// parsePolicies decodes and validates the policies in a file with the given extension.
+func parsePolicies(data []byte, ext string) (map[string]Policy, error) {
+	var policies map[string]Policy
+	switch strings.ToLower(ext) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&policies); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&policies); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy file extension %q, expected .yaml, .yml or .json", ext)
+	}
+
+	if err := validatePolicies(policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// validatePolicies validates each policy, in alphabetical order so that the same error is always reported.
+func validatePolicies(policies map[string]Policy) error {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("policy name must not be empty")
+		}
+		if err := policies[name].Validate(); err != nil {
+			return fmt.Errorf("invalid policy %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// headerWriter is a http.ResponseWriter that only records headers.
+type headerWriter struct {
+	header http.Header
+}
+
+func (hw *headerWriter) Header() http.Header         { return hw.header }
+func (hw *headerWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (hw *headerWriter) WriteHeader(int)             {}
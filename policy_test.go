@@ -0,0 +1,125 @@
+package htmxheaders_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+const policiesYAML = `
+validationError:
+  retarget: "#errors"
+  reswap: outerHTML show:top
+  trigger: shake
+saved:
+  push-url: /orders
+  trigger-after-swap:
+    - name: saved
+      detail: {message: Saved}
+      target: "#toast"
+    - closeModal
+`
+
+func writePolicies(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPoliciesYAML(t *testing.T) {
+	policies, err := hh.LoadPolicies(writePolicies(t, "policies.yaml", policiesYAML))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"saved", "validationError"}, policies.Names())
+
+	w := httptest.NewRecorder()
+	require.NoError(t, policies.Apply(w, "validationError"))
+	assert.Equal(t, "#errors", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "outerHTML show:top", w.Header().Get("HX-Reswap"))
+	assert.Equal(t, "shake", w.Header().Get("HX-Trigger"))
+
+	w = httptest.NewRecorder()
+	require.NoError(t, policies.Apply(w, "saved"))
+	assert.Equal(t, "/orders", w.Header().Get("HX-Push-Url"))
+	assert.Equal(t, `{"saved":{"message":"Saved","target":"#toast"},"closeModal":null}`, w.Header().Get("HX-Trigger-After-Swap"))
+}
+
+func TestLoadPoliciesJSON(t *testing.T) {
+	path := writePolicies(t, "policies.json", `{"loggedOut": {"redirect": "/login", "trigger": ["sessionEnded"]}}`)
+
+	policies, err := hh.LoadPolicies(path)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, policies.Apply(w, "loggedOut"))
+	assert.Equal(t, "/login", w.Header().Get("HX-Redirect"))
+	assert.Equal(t, "sessionEnded", w.Header().Get("HX-Trigger"))
+}
+
+func TestLoadPoliciesValidates(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "validationError:\n  retarget: '#errors'\n  colour: red\n",
+		"invalid swap":    "validationError:\n  reswap: sideways\n",
+		"invalid target":  "validationError:\n  retarget: '#errors[data-x'\n",
+		"empty policy":    "validationError: {}\n",
+		"duplicate event": "validationError:\n  trigger: [shake, shake]\n",
+		"empty event":     "validationError:\n  trigger: [{detail: 1}]\n",
+	}
+
+	for name, content := range tests {
+		_, err := hh.LoadPolicies(writePolicies(t, "policies.yaml", content))
+		assert.Error(t, err, name)
+	}
+
+	_, err := hh.LoadPolicies(writePolicies(t, "policies.toml", ""))
+	assert.Error(t, err)
+}
+
+func TestApplyPolicy(t *testing.T) {
+	policies, err := hh.NewPolicies(map[string]hh.Policy{"refresh": {Refresh: true}})
+	require.NoError(t, err)
+	hh.SetPolicies(policies)
+	defer hh.SetPolicies(nil)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, hh.ApplyPolicy(w, "refresh"))
+	assert.Equal(t, "true", w.Header().Get("HX-Refresh"))
+
+	err = hh.ApplyPolicy(w, "missing")
+	assert.True(t, errors.Is(err, hh.ErrUnknownPolicy))
+}
+
+func TestPoliciesWatchReloadsOnChange(t *testing.T) {
+	path := writePolicies(t, "policies.yaml", "validationError:\n  retarget: '#errors'\n")
+	policies, err := hh.LoadPolicies(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	go policies.Watch(ctx, 10*time.Millisecond, func(err error) { errs <- err })
+
+	require.NoError(t, os.WriteFile(path, []byte("validationError:\n  reswap: sideways\n"), 0o600))
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("invalid policies were not reported")
+	}
+	policy, _ := policies.Lookup("validationError")
+	assert.Equal(t, "#errors", policy.Retarget)
+
+	require.NoError(t, os.WriteFile(path, []byte("validationError:\n  retarget: '#form-errors'\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		policy, _ := policies.Lookup("validationError")
+		return policy.Retarget == "#form-errors"
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,52 @@
+package htmxheaders
+
+import (
+	"net/http"
+)
+
+// Request represents the HTMX request headers sent by the client.
+// https://htmx.org/reference/#request_headers
+type Request struct {
+	Enabled               bool   `json:"enabled"`                         // HX-Request: always true for requests made by htmx
+	Boosted               bool   `json:"boosted,omitempty"`               // HX-Boosted: the request is via an element using hx-boost
+	CurrentURL            string `json:"currentUrl,omitempty"`            // HX-Current-URL: the current URL of the browser
+	HistoryRestoreRequest bool   `json:"historyRestoreRequest,omitempty"` // HX-History-Restore-Request: the request is for history restoration after a miss in the local history cache
+	Prompt                string `json:"prompt,omitempty"`                // HX-Prompt: the user response to an hx-prompt
+	Target                string `json:"target,omitempty"`                // HX-Target: the id of the target element if it exists
+	TriggerName           string `json:"triggerName,omitempty"`           // HX-Trigger-Name: the name of the triggered element if it exists
+	Trigger               string `json:"trigger,omitempty"`               // HX-Trigger: the id of the triggered element if it exists
+}
+
+// ParseRequest reads the HTMX request headers from the provided http.Request.
+// Each field is read from its header whether or not HX-Request is set, so Enabled should be checked
+// to know whether the request was made by htmx. A nil request results in a zero Request.
+func ParseRequest(r *http.Request) Request {
+	if r == nil {
+		return Request{}
+	}
+	return parseRequestHeader(r.Header)
+}
+
+func parseRequestHeader(h http.Header) Request {
+	return Request{
+		Enabled:               h.Get("HX-Request") == "true",
+		Boosted:               h.Get("HX-Boosted") == "true",
+		CurrentURL:            h.Get("HX-Current-URL"),
+		HistoryRestoreRequest: h.Get("HX-History-Restore-Request") == "true",
+		Prompt:                h.Get("HX-Prompt"),
+		Target:                h.Get("HX-Target"),
+		TriggerName:           h.Get("HX-Trigger-Name"),
+		Trigger:               h.Get("HX-Trigger"),
+	}
+}
+
+// IsHTMXRequest reports whether the request was made by htmx, that is, whether the
+// HX-Request header is present and set to true.
+func IsHTMXRequest(r *http.Request) bool {
+	return r != nil && r.Header.Get("HX-Request") == "true"
+}
+
+// IsBoostedRequest reports whether the request was made by htmx via an element using hx-boost.
+func IsBoostedRequest(r *http.Request) bool {
+	return IsHTMXRequest(r) && r.Header.Get("HX-Boosted") == "true"
+}
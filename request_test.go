@@ -0,0 +1,47 @@
+package htmxheaders_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestParseRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+	r.Header.Set("HX-Current-URL", "http://example.com/page")
+	r.Header.Set("HX-Prompt", "yes")
+	r.Header.Set("HX-Target", "cart")
+	r.Header.Set("HX-Trigger-Name", "quantity")
+	r.Header.Set("HX-Trigger", "quantity-input")
+
+	want := hh.Request{
+		Enabled:     true,
+		Boosted:     true,
+		CurrentURL:  "http://example.com/page",
+		Prompt:      "yes",
+		Target:      "cart",
+		TriggerName: "quantity",
+		Trigger:     "quantity-input",
+	}
+	assert.Equal(t, want, hh.ParseRequest(r))
+}
+
+func TestParseRequestWithoutHTMXHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, hh.Request{}, hh.ParseRequest(r))
+	assert.False(t, hh.IsHTMXRequest(r))
+	assert.False(t, hh.IsBoostedRequest(r))
+}
+
+func TestIsBoostedRequestRequiresHXRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("HX-Boosted", "true")
+	assert.False(t, hh.IsBoostedRequest(r))
+
+	r.Header.Set("HX-Request", "true")
+	assert.True(t, hh.IsBoostedRequest(r))
+}
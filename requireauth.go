@@ -0,0 +1,114 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NextParam is the name of the query parameter added to the login URL by RequireAuth,
+// holding the page the user should return to after logging in.
+const NextParam = "next"
+
+// Authenticator reports whether a request has been made by an authenticated user.
+// It allows RequireAuth to work with any session or authentication library.
+type Authenticator interface {
+	Authenticated(r *http.Request) bool
+}
+
+// AuthenticatorFunc is an adapter allowing an ordinary function to be used as an Authenticator.
+type AuthenticatorFunc func(r *http.Request) bool
+
+// Authenticated calls f(r).
+func (f AuthenticatorFunc) Authenticated(r *http.Request) bool {
+	return f(r)
+}
+
+// RequireAuth returns a http.Handler that only calls next for authenticated requests and
+// sends all other requests to the login page.
+//
+// Unauthenticated htmx requests receive a 401 response with the HX-Redirect header set to loginURL,
+// so that the browser navigates to the login page rather than htmx swapping it into the target element.
+// The NextParam query parameter is taken from the HX-Current-URL header, the page the user was on,
+// rather than the path of the fragment endpoint that was requested. Boosted GET requests use the requested
+// path, as that is the page being navigated to. Unauthenticated browser requests receive a 303 redirect
+// with the NextParam set to the requested path.
+//
+// Example usage:
+//
+//	auth := hh.AuthenticatorFunc(func(r *http.Request) bool {
+//	    _, err := r.Cookie("session")
+//	    return err == nil
+//	})
+//	http.Handle("/account/", hh.RequireAuth(accountHandler, auth, "/login"))
+//
+// Note:
+//
+//	Only the path, query and fragment of the HX-Current-URL header are used, and leading slashes and
+//	backslashes are collapsed to a single slash, so that the NextParam cannot be used to redirect the
+//	user to a different host with a protocol-relative URL such as //evil.example.com.
+func RequireAuth(next http.Handler, auth Authenticator, loginURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.Authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !IsHTMXRequest(r) {
+			http.Redirect(w, r, loginRedirectURL(loginURL, localPath(r.URL.RequestURI())), http.StatusSeeOther)
+			return
+		}
+
+		returnTo := localPath(r.URL.RequestURI())
+		if !IsBoostedRequest(r) || r.Method != http.MethodGet {
+			returnTo = localURL(ParseRequest(r).CurrentURL)
+		}
+
+		if err := SetResponseHeaders(w, Redirect(loginRedirectURL(loginURL, returnTo))); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+// loginRedirectURL adds the NextParam to the loginURL, unless returnTo is empty.
+func loginRedirectURL(loginURL, returnTo string) string {
+	if returnTo == "" {
+		return loginURL
+	}
+
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		return loginURL
+	}
+
+	query := u.Query()
+	query.Set(NextParam, returnTo)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// localURL strips the scheme and host from the given URL, returning an empty string if it cannot be parsed.
+func localURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return ""
+	}
+
+	local := url.URL{Path: localPath(u.Path), RawPath: localPath(u.RawPath), RawQuery: u.RawQuery, Fragment: u.Fragment}
+	if local.Path == "" {
+		local.Path = "/"
+	}
+	return local.String()
+}
+
+// localPath collapses the leading slashes and backslashes of a path to a single slash, as browsers
+// treat paths such as //evil.example.com and /\evil.example.com as URLs on a different host.
+func localPath(path string) string {
+	trimmed := strings.TrimLeft(path, `/\`)
+	if len(trimmed) == len(path) {
+		return path
+	}
+	return "/" + trimmed
+}
@@ -0,0 +1,126 @@
+package htmxheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func authenticated(ok bool) hh.Authenticator {
+	return hh.AuthenticatorFunc(func(r *http.Request) bool { return ok })
+}
+
+func TestRequireAuthCallsNextWhenAuthenticated(t *testing.T) {
+	r := httptest.NewRequest("GET", "/account", nil)
+	w := httptest.NewRecorder()
+
+	hh.RequireAuth(okHandler, authenticated(true), "/login").ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("HX-Redirect"))
+}
+
+func TestRequireAuthRedirectsBrowserRequests(t *testing.T) {
+	r := httptest.NewRequest("GET", "/account?tab=orders", nil)
+	w := httptest.NewRecorder()
+
+	hh.RequireAuth(okHandler, authenticated(false), "/login").ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login?next=%2Faccount%3Ftab%3Dorders", w.Header().Get("Location"))
+}
+
+func TestRequireAuthUsesCurrentURLForHTMXRequests(t *testing.T) {
+	r := httptest.NewRequest("POST", "/account/fragments/orders", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Current-URL", "https://evil.example.com/account?tab=orders")
+	w := httptest.NewRecorder()
+
+	hh.RequireAuth(okHandler, authenticated(false), "/login?lang=en").ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "/login?lang=en&next=%2Faccount%3Ftab%3Dorders", w.Header().Get("HX-Redirect"))
+	assert.Empty(t, w.Header().Get("Location"))
+}
+
+func TestRequireAuthUsesRequestedPathForBoostedNavigation(t *testing.T) {
+	r := httptest.NewRequest("GET", "/account", nil)
+	r.Header.Set("HX-Request", "true")
+	r.Header.Set("HX-Boosted", "true")
+	r.Header.Set("HX-Current-URL", "http://example.com/")
+	w := httptest.NewRecorder()
+
+	hh.RequireAuth(okHandler, authenticated(false), "/login").ServeHTTP(w, r)
+
+	assert.Equal(t, "/login?next=%2Faccount", w.Header().Get("HX-Redirect"))
+}
+
+func TestRequireAuthWithoutCurrentURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "/fragment", nil)
+	r.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+
+	hh.RequireAuth(okHandler, authenticated(false), "/login").ServeHTTP(w, r)
+
+	assert.Equal(t, "/login", w.Header().Get("HX-Redirect"))
+}
+
+func TestRequireAuthDoesNotRedirectToOtherHosts(t *testing.T) {
+	testCases := []struct {
+		name       string
+		currentURL string
+		want       string
+	}{
+		{"protocol relative path", "https://app.example.com//evil.example.com/a", "/login?next=%2Fevil.example.com%2Fa"},
+		{"many slashes", "https://app.example.com////evil.example.com", "/login?next=%2Fevil.example.com"},
+		{"backslash", `https://app.example.com/\evil.example.com/a`, "/login?next=%2Fevil.example.com%2Fa"},
+		{"slash backslash mix", `https://app.example.com/\/evil.example.com`, "/login?next=%2Fevil.example.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/fragment", nil)
+			r.Header.Set("HX-Request", "true")
+			r.Header.Set("HX-Current-URL", tc.currentURL)
+			w := httptest.NewRecorder()
+
+			hh.RequireAuth(okHandler, authenticated(false), "/login").ServeHTTP(w, r)
+
+			assert.Equal(t, tc.want, w.Header().Get("HX-Redirect"))
+		})
+	}
+}
+
+func TestRequireAuthDoesNotRedirectBrowserRequestsToOtherHosts(t *testing.T) {
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{"//evil.example.com/a", "/evil.example.com/a"},
+		{`/\evil.example.com/a`, "/%5Cevil.example.com/a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.URL.Path = tc.path
+			w := httptest.NewRecorder()
+
+			hh.RequireAuth(okHandler, authenticated(false), "/login").ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			location, err := url.Parse(w.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, location.Query().Get(hh.NextParam))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package htmxheaders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Response represents the HTMX response headers set on a response, decoded into their parts.
+// https://htmx.org/reference/#response_headers
+type Response struct {
+	Location           *LocationContextWithPath `json:"location,omitempty"`           // HX-Location: a client-side redirect, with its context if it was sent as JSON
+	PushURL            string                   `json:"pushUrl,omitempty"`            // HX-Push-Url: the URL pushed into the history stack, or "false"
+	Redirect           string                   `json:"redirect,omitempty"`           // HX-Redirect: a client-side redirect with a full page reload
+	Refresh            bool                     `json:"refresh,omitempty"`            // HX-Refresh: the client does a full refresh of the page
+	ReplaceURL         string                   `json:"replaceUrl,omitempty"`         // HX-Replace-Url: the URL replacing the current location, or "false"
+	Reswap             *SwapSpec                `json:"reswap,omitempty"`             // HX-Reswap: how the response will be swapped
+	Retarget           string                   `json:"retarget,omitempty"`           // HX-Retarget: the CSS selector of the element updated instead of the target
+	Reselect           string                   `json:"reselect,omitempty"`           // HX-Reselect: the CSS selector of the part of the response swapped in
+	Trigger            []TriggerEvent           `json:"trigger,omitempty"`            // HX-Trigger: the events triggered as soon as the response is received
+	TriggerAfterSettle []TriggerEvent           `json:"triggerAfterSettle,omitempty"` // HX-Trigger-After-Settle: the events triggered after the settle step
+	TriggerAfterSwap   []TriggerEvent           `json:"triggerAfterSwap,omitempty"`   // HX-Trigger-After-Swap: the events triggered after the swap step
+}
+
+// ParseResponse decodes the HTMX response headers. The JSON context of HX-Location, the modifiers of HX-Reswap
+// and the events of the trigger headers are decoded, so that they can be inspected or asserted in tests.
+//
+// Headers that cannot be decoded are left unset in the returned Response, and the errors decoding them
+// are joined in the returned error.
+//
+// Example usage:
+//
+//	resp, err := hh.ParseResponse(w.Result().Header)
+//	if err != nil {
+//	    // Handle error
+//	}
+//	fmt.Println(resp.Location.Path, resp.TriggerAfterSwap[0].Name)
+func ParseResponse(h http.Header) (Response, error) {
+	resp := Response{
+		PushURL:    h.Get("HX-Push-Url"),
+		Redirect:   h.Get("HX-Redirect"),
+		Refresh:    h.Get("HX-Refresh") == "true",
+		ReplaceURL: h.Get("HX-Replace-Url"),
+		Retarget:   h.Get("HX-Retarget"),
+		Reselect:   h.Get("HX-Reselect"),
+	}
+
+	var errs []error
+	if location := h.Get("HX-Location"); location != "" {
+		resp.Location = &LocationContextWithPath{Path: location}
+		if strings.HasPrefix(strings.TrimSpace(location), "{") {
+			resp.Location = &LocationContextWithPath{}
+			if err := json.Unmarshal([]byte(location), resp.Location); err != nil {
+				resp.Location = nil
+				errs = append(errs, fmt.Errorf("error decoding HX-Location: %w", err))
+			}
+		}
+	}
+
+	if reswap := h.Get("HX-Reswap"); reswap != "" {
+		spec, err := ParseSwapSpec(reswap)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error decoding HX-Reswap: %w", err))
+		} else {
+			resp.Reswap = &spec
+		}
+	}
+
+	for _, trigger := range []struct {
+		when   TriggerDelay
+		events *[]TriggerEvent
+	}{
+		{TriggerImmediately, &resp.Trigger},
+		{TriggerAfterSettle, &resp.TriggerAfterSettle},
+		{TriggerAfterSwap, &resp.TriggerAfterSwap},
+	} {
+		events, err := DecodeTriggerHeader(h.Get(trigger.when.String()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error decoding %s: %w", trigger.when, err))
+			continue
+		}
+		*trigger.events = events
+	}
+
+	return resp, errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package htmxheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestParseResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := hh.SetResponseHeaders(w,
+		hh.LocationWithContext("/orders", hh.LocationContext{Target: "#main"}),
+		hh.PushURL("/orders/7"),
+		hh.ReswapSpec(hh.SwapSpec{Swap: hh.SwapOuterHTML, SettleDelay: time.Second}),
+		hh.Retarget("#errors"),
+		hh.Trigger(hh.TriggerImmediately, "shake"),
+		hh.TriggerWithDetail(hh.TriggerAfterSwap, hh.TriggerEvent{Name: "saved", Detail: map[string]any{"id": 7.0}}),
+	)
+	require.NoError(t, err)
+
+	resp, err := hh.ParseResponse(w.Header())
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.Location)
+	assert.Equal(t, "/orders", resp.Location.Path)
+	assert.Equal(t, "#main", resp.Location.Target)
+	assert.Equal(t, "/orders/7", resp.PushURL)
+	assert.Equal(t, &hh.SwapSpec{Swap: hh.SwapOuterHTML, SettleDelay: time.Second}, resp.Reswap)
+	assert.Equal(t, "#errors", resp.Retarget)
+	assert.Equal(t, []hh.TriggerEvent{{Name: "shake"}}, resp.Trigger)
+	assert.Equal(t, []hh.TriggerEvent{{Name: "saved", Detail: map[string]any{"id": 7.0}}}, resp.TriggerAfterSwap)
+	assert.False(t, resp.Refresh)
+}
+
+func TestParseResponseLocationPath(t *testing.T) {
+	h := http.Header{}
+	h.Set("HX-Location", "/login")
+	h.Set("HX-Refresh", "true")
+
+	resp, err := hh.ParseResponse(h)
+
+	require.NoError(t, err)
+	assert.Equal(t, &hh.LocationContextWithPath{Path: "/login"}, resp.Location)
+	assert.True(t, resp.Refresh)
+}
+
+func TestParseResponseLocationSwap(t *testing.T) {
+	for _, location := range []string{
+		`{"path":"/orders","swap":"outerHTML"}`,
+		`{"path":"/orders","swap":"outerHTML show:top swap:100ms"}`,
+		`{"path":"/orders","swap":1}`,
+	} {
+		t.Run(location, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("HX-Location", location)
+
+			resp, err := hh.ParseResponse(h)
+
+			require.NoError(t, err)
+			require.NotNil(t, resp.Location)
+			assert.Equal(t, hh.SwapOuterHTML, resp.Location.Swap)
+		})
+	}
+}
+
+func TestParseResponseErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("HX-Location", "{broken")
+	w.Header().Set("HX-Reswap", "sideways")
+	w.Header().Set("HX-Retarget", "#errors")
+
+	resp, err := hh.ParseResponse(w.Header())
+
+	assert.ErrorContains(t, err, "HX-Location")
+	assert.ErrorContains(t, err, "HX-Reswap")
+	assert.Nil(t, resp.Location)
+	assert.Nil(t, resp.Reswap)
+	assert.Equal(t, "#errors", resp.Retarget)
+}
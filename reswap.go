@@ -1,6 +1,9 @@
 package htmxheaders
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Swap represents the type of content swap method used in HTMX.
 // It enumerates different ways in which content can be swapped on the client-side
@@ -44,6 +47,34 @@ func (s Swap) String() string {
 	}
 }
 
+// MarshalJSON encodes the Swap as its string representation, such as "outerHTML", as htmx expects
+// in the swap of an HX-Location context.
+func (s Swap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a Swap from an hx-swap value, as documented by htmx, or from its numeric value.
+// The value is parsed with ParseSwapSpec, so a swap with modifiers, such as "outerHTML show:top", is accepted,
+// and only its swap method is kept.
+func (s *Swap) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var n int64
+		if json.Unmarshal(data, &n) != nil {
+			return fmt.Errorf("invalid Swap value: %s", data)
+		}
+		*s = Swap(n)
+		return nil
+	}
+
+	spec, err := ParseSwapSpec(name)
+	if err != nil {
+		return err
+	}
+	*s = spec.Swap
+	return nil
+}
+
 // SwapFromString converts a string representation to a Swap value.
 // If the provided string does not match any known Swap values, it returns SwapInnerHTML by default
 // along with an error indicating the invalid string value.
@@ -1,8 +1,73 @@
 package htmxheaders
 
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
 // Retarget a CSS selector that overrides the target of the content update to
 // a different element on the page.
+// An error is returned if the selector is empty, contains control characters or has unbalanced brackets or quotes.
 // https://htmx.org/reference/#response_headers
 func Retarget(target string) DecoratorFunction {
-	return AddCustomHeader("HX-Retarget", target)
+	return func(w http.ResponseWriter) error {
+		if err := validateSelector(target); err != nil {
+			return fmt.Errorf("invalid HX-Retarget: %w", err)
+		}
+
+		w.Header().Set("HX-Retarget", target)
+		return nil
+	}
+}
+
+// validateSelector checks that the selector is not empty, contains no control characters,
+// which could not be sent in a header, and has balanced brackets, parentheses and quotes.
+// Extended selectors used by htmx, such as "closest form" or "next .error", are accepted.
+func validateSelector(selector string) error {
+	if strings.TrimSpace(selector) == "" {
+		return fmt.Errorf("selector must not be empty")
+	}
+
+	var stack []rune
+	var quote rune
+	var escaped bool
+	for _, r := range selector {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("selector %q must not contain control characters", selector)
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '(':
+			stack = append(stack, r)
+		case r == ']' || r == ')':
+			open := '['
+			if r == ')' {
+				open = '('
+			}
+			if len(stack) == 0 || stack[len(stack)-1] != open {
+				return fmt.Errorf("selector %q has unbalanced %q", selector, r)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("selector %q has an unterminated string", selector)
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("selector %q has unbalanced %q", selector, stack[len(stack)-1])
+	}
+	return nil
 }
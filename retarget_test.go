@@ -1,6 +1,7 @@
 package htmxheaders_test
 
 import (
+	"github.com/stretchr/testify/assert"
 	hh "github.com/thisisthemurph/htmxheaders"
 	"net/http/httptest"
 	"testing"
@@ -20,3 +21,46 @@ func TestRetarget(t *testing.T) {
 		t.Errorf("Expected header HX-Refresh to have value %s, got %s", target, header)
 	}
 }
+
+func TestRetargetAcceptsExtendedSelectors(t *testing.T) {
+	selectors := []string{
+		"#swap-target",
+		"closest form",
+		"next .error",
+		`input[name="email"]`,
+		`li:not(.done)`,
+		`[data-label="a \"quoted\" ]"]`,
+	}
+
+	for _, selector := range selectors {
+		t.Run(selector, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := hh.SetResponseHeaders(w, hh.Retarget(selector))
+
+			assert.NoError(t, err)
+			assert.Equal(t, selector, w.Header().Get("HX-Retarget"))
+		})
+	}
+}
+
+func TestRetargetRejectsInvalidSelectors(t *testing.T) {
+	selectors := []string{
+		"",
+		"   ",
+		"#target\r\nSet-Cookie: a=b",
+		`input[name="email"`,
+		`li:not(.done`,
+		`a]`,
+		`[data-label="unterminated]`,
+	}
+
+	for _, selector := range selectors {
+		t.Run(selector, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := hh.SetResponseHeaders(w, hh.Retarget(selector))
+
+			assert.Error(t, err)
+			assert.Empty(t, w.Header().Get("HX-Retarget"))
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package htmxheaders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSSEClosed is returned when writing to an SSEWriter that has been closed,
+// either explicitly or because the request context has ended.
+var ErrSSEClosed = errors.New("sse writer closed")
+
+// SSEEvent represents a single server-sent event.
+// When used with the htmx SSE extension, the Event is the name listened for by sse-swap
+// and the Data is the HTML fragment that will be swapped in.
+// https://htmx.org/extensions/server-sent-events/
+type SSEEvent struct {
+	ID    string        // sets the last event ID, sent back by the browser as Last-Event-ID when reconnecting
+	Event string        // the name of the event; the default "message" event is used if empty
+	Data  string        // the data of the event, which may contain multiple lines
+	Retry time.Duration // the reconnection time used by the browser, ignored if zero
+}
+
+// SSEWriter writes server-sent events to a http.ResponseWriter.
+// It is safe for concurrent use.
+type SSEWriter struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	ctx    context.Context
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewSSEWriter sets the headers required for server-sent events, writes the response status and
+// flushes them to the client, returning an SSEWriter for sending events.
+//
+// The writer is closed when the request context ends, after which all writes return ErrSSEClosed.
+// An error is returned if the provided http.ResponseWriter does not support flushing.
+//
+// Example usage:
+//
+//	sse, err := hh.NewSSEWriter(w, r)
+//	if err != nil {
+//	    // Handle error
+//	}
+//	defer sse.Close()
+//	sse.Heartbeat(15 * time.Second)
+//
+//	for order := range orders {
+//	    if err := sse.Render("newOrder", func(w io.Writer) error {
+//	        return tmpl.ExecuteTemplate(w, "order", order)
+//	    }); err != nil {
+//	        return
+//	    }
+//	}
+//
+// Note:
+//
+//	Any HX headers should be set before calling NewSSEWriter, as the headers are written immediately.
+func NewSSEWriter(w http.ResponseWriter, r *http.Request) (*SSEWriter, error) {
+	// Check before the status is written, so that the caller can still respond with an error.
+	if !canFlush(w) {
+		return nil, fmt.Errorf("response writer does not support flushing: %w", http.ErrNotSupported)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	h.Del("Content-Length")
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, fmt.Errorf("response writer does not support flushing: %w", err)
+	}
+
+	s := &SSEWriter{
+		w:    w,
+		rc:   rc,
+		ctx:  r.Context(),
+		done: make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-s.ctx.Done():
+			s.shutdown()
+		case <-s.done:
+		}
+	}()
+
+	return s, nil
+}
+
+// canFlush reports whether http.ResponseController can flush the writer, checking FlushError, http.Flusher and
+// then Unwrap in the same order it does, without writing anything.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case interface{ FlushError() error }:
+			return true
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// Send writes the event to the client and flushes it.
+// An error is returned if the ID or Event contain line breaks, or if the writer has been closed.
+func (s *SSEWriter) Send(event SSEEvent) error {
+	if strings.ContainsAny(event.Event, "\r\n") {
+		return fmt.Errorf("invalid SSE event name %q: must not contain line breaks", event.Event)
+	}
+	if strings.ContainsAny(event.ID, "\r\n\x00") {
+		return fmt.Errorf("invalid SSE event ID %q: must not contain line breaks or NULL", event.ID)
+	}
+
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	writeSSEData(&buf, event.Data)
+	buf.WriteString("\n")
+
+	return s.write(buf.Bytes())
+}
+
+// SendHTML sends a named event whose data is the provided HTML fragment.
+func (s *SSEWriter) SendHTML(event, html string) error {
+	return s.Send(SSEEvent{Event: event, Data: html})
+}
+
+// Render sends a named event whose data is the HTML fragment written by the render function.
+// Nothing is sent if the render function returns an error.
+func (s *SSEWriter) Render(event string, render func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return err
+	}
+	return s.SendHTML(event, buf.String())
+}
+
+// Retry sets the time the browser will wait before reconnecting after the connection is lost.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.write([]byte("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n"))
+}
+
+// Comment writes a comment line, which is ignored by the browser but keeps the connection alive.
+func (s *SSEWriter) Comment(text string) error {
+	var buf bytes.Buffer
+	for _, line := range splitSSELines(text) {
+		buf.WriteString(": " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return s.write(buf.Bytes())
+}
+
+// Heartbeat starts sending a comment at the given interval to prevent proxies from closing
+// an idle connection. The heartbeat stops when the writer is closed. Intervals of zero or less are ignored.
+func (s *SSEWriter) Heartbeat(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Comment("heartbeat"); err != nil {
+					return
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Done returns a channel that is closed when the writer is closed.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close stops the heartbeat and prevents any further writes. It waits for the background goroutines
+// to finish, so it must be called before the handler returns. Calling Close more than once is safe.
+func (s *SSEWriter) Close() {
+	s.shutdown()
+	s.wg.Wait()
+}
+
+func (s *SSEWriter) shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+func (s *SSEWriter) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// writeSSEData writes the data as one data field per line, so that multi-line HTML
+// is reassembled by the browser exactly as it was sent.
+func writeSSEData(buf *bytes.Buffer, data string) {
+	for _, line := range splitSSELines(data) {
+		buf.WriteString("data: " + line + "\n")
+	}
+}
+
+// splitSSELines splits the text on any of the line endings recognised by the SSE specification.
+func splitSSELines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,167 @@
+package htmxheaders_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestNewSSEWriterSetsHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	sse.Close()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.True(t, w.Flushed)
+}
+
+func TestSSEWriterSend(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	err = sse.Send(hh.SSEEvent{ID: "42", Event: "newOrder", Data: "<li>\r\n  Order 42\r\n</li>", Retry: 3 * time.Second})
+	require.NoError(t, err)
+
+	expected := "id: 42\nevent: newOrder\nretry: 3000\ndata: <li>\ndata:   Order 42\ndata: </li>\n\n"
+	assert.Equal(t, expected, w.Body.String())
+}
+
+func TestSSEWriterRender(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	err = sse.Render("message", func(w io.Writer) error {
+		_, err := io.WriteString(w, "<p>Hello</p>")
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "event: message\ndata: <p>Hello</p>\n\n", w.Body.String())
+
+	renderErr := errors.New("render failed")
+	err = sse.Render("message", func(w io.Writer) error { return renderErr })
+	assert.ErrorIs(t, err, renderErr)
+	assert.Equal(t, "event: message\ndata: <p>Hello</p>\n\n", w.Body.String())
+}
+
+func TestSSEWriterRejectsInvalidEventNames(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	assert.Error(t, sse.SendHTML("bad\nname", "<p></p>"))
+	assert.Error(t, sse.Send(hh.SSEEvent{ID: "1\n2", Data: "x"}))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestSSEWriterClosesWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	cancel()
+	select {
+	case <-sse.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected writer to close when the request context ended")
+	}
+
+	assert.ErrorIs(t, sse.SendHTML("message", "<p></p>"), hh.ErrSSEClosed)
+}
+
+func TestSSEWriterHeartbeat(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+
+	sse.Heartbeat(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	sse.Close()
+
+	assert.True(t, strings.HasPrefix(w.Body.String(), ": heartbeat\n\n"))
+}
+
+// nonFlushingWriter hides the Flush method of the wrapped recorder.
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestNewSSEWriterWithoutFlushSupportWritesNothing(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	_, err := hh.NewSSEWriter(nonFlushingWriter{rec}, r)
+	require.ErrorIs(t, err, http.ErrNotSupported)
+
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+
+	http.Error(rec, "streaming unsupported", http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+// flushingWrapper flushes the recorder itself, although the writer it unwraps to cannot flush.
+type flushingWrapper struct {
+	nonFlushingWriter
+	rec *httptest.ResponseRecorder
+}
+
+func (w flushingWrapper) Flush() { w.rec.Flush() }
+
+func (w flushingWrapper) Unwrap() http.ResponseWriter { return w.nonFlushingWriter }
+
+func TestNewSSEWriterUsesFlushOfWrapper(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(flushingWrapper{nonFlushingWriter{rec}, rec}, r)
+	require.NoError(t, err)
+	sse.Close()
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+}
+
+func TestSSEWriterHeartbeatIgnoresNonPositiveIntervals(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	sse, err := hh.NewSSEWriter(w, r)
+	require.NoError(t, err)
+	defer sse.Close()
+
+	assert.NotPanics(t, func() {
+		sse.Heartbeat(0)
+		sse.Heartbeat(-time.Second)
+	})
+}
@@ -0,0 +1,248 @@
+package htmxheaders
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrSlowConsumer is returned by SSEHub.Stream when the client was evicted for not
+// keeping up with the events published to its topics.
+var ErrSlowConsumer = errors.New("sse client evicted: too slow to consume events")
+
+// SSEHubOptions configures an SSEHub.
+type SSEHubOptions struct {
+	ReplaySize   int           // the number of events kept per topic for Last-Event-ID replay, defaults to 100
+	ClientBuffer int           // the number of events buffered per client before it is evicted, defaults to 16
+	Heartbeat    time.Duration // the interval between heartbeat comments, no heartbeat is sent if zero
+}
+
+// SSETopicStats holds the metrics of a single SSEHub topic.
+type SSETopicStats struct {
+	Topic       string // the name of the topic
+	Subscribers int    // the number of clients currently subscribed
+	Published   uint64 // the number of events published
+	Evicted     uint64 // the number of clients evicted for being too slow
+}
+
+// SSEHub broadcasts events published to a topic to every client subscribed to the topic.
+//
+// Each event is given a unique, increasing ID, and the most recent events of each topic are kept
+// so that reconnecting clients sending the Last-Event-ID header receive the events they missed.
+// Clients are given a bounded buffer, and are evicted rather than blocking publishers when it is full;
+// the browser then reconnects and catches up from the replay buffer.
+type SSEHub struct {
+	opts   SSEHubOptions
+	mu     sync.Mutex
+	seq    uint64
+	topics map[string]*sseTopic
+}
+
+type sseTopic struct {
+	subscribers map[*sseSubscriber]struct{}
+	replay      []SSEEvent
+	published   uint64
+	evicted     uint64
+}
+
+type sseSubscriber struct {
+	events  chan SSEEvent
+	evicted chan struct{}
+	topics  []string
+}
+
+// NewSSEHub creates an SSEHub with the given options.
+func NewSSEHub(opts SSEHubOptions) *SSEHub {
+	if opts.ReplaySize <= 0 {
+		opts.ReplaySize = 100
+	}
+	if opts.ClientBuffer <= 0 {
+		opts.ClientBuffer = 16
+	}
+
+	return &SSEHub{
+		opts:   opts,
+		topics: map[string]*sseTopic{},
+	}
+}
+
+// Publish sends a named event whose data is the provided HTML fragment to every client subscribed to the topic.
+// It never blocks; clients whose buffer is full are evicted. The ID given to the event is returned.
+//
+// Example usage:
+//
+//	hub := hh.NewSSEHub(hh.SSEHubOptions{Heartbeat: 15 * time.Second})
+//	http.Handle("/orders/events", hub.Handler("orders"))
+//
+//	// In the handler creating an order
+//	hub.Publish("orders", "newOrder", renderOrder(order))
+func (h *SSEHub) Publish(topic, event, html string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	e := SSEEvent{ID: strconv.FormatUint(h.seq, 10), Event: event, Data: html}
+
+	t := h.topic(topic)
+	t.published++
+	t.replay = append(t.replay, e)
+	if len(t.replay) > h.opts.ReplaySize {
+		t.replay = t.replay[len(t.replay)-h.opts.ReplaySize:]
+	}
+
+	for sub := range t.subscribers {
+		select {
+		case sub.events <- e:
+		default:
+			t.evicted++
+			h.unsubscribe(sub)
+			close(sub.evicted)
+		}
+	}
+
+	return e.ID
+}
+
+// Stream subscribes the client to the topics and writes their events to w until the request context
+// ends or the client is evicted. Events missed since the Last-Event-ID header, if present, are sent first.
+// ErrSlowConsumer is returned if the client was evicted.
+func (h *SSEHub) Stream(w http.ResponseWriter, r *http.Request, topics ...string) error {
+	sse, err := NewSSEWriter(w, r)
+	if err != nil {
+		return err
+	}
+	defer sse.Close()
+
+	if h.opts.Heartbeat > 0 {
+		sse.Heartbeat(h.opts.Heartbeat)
+	}
+
+	sub, missed := h.subscribe(topics, r.Header.Get("Last-Event-ID"))
+	defer func() {
+		h.mu.Lock()
+		h.unsubscribe(sub)
+		h.mu.Unlock()
+	}()
+
+	for _, e := range missed {
+		if err := sse.Send(e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case e := <-sub.events:
+			if err := sse.Send(e); err != nil {
+				return err
+			}
+		case <-sub.evicted:
+			return ErrSlowConsumer
+		case <-sse.Done():
+			return nil
+		}
+	}
+}
+
+// Handler returns a http.Handler streaming the events of the given topics to each client.
+// Errors occurring once the stream has started cannot be reported to the client and are discarded.
+func (h *SSEHub) Handler(topics ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Stream(w, r, topics...); errors.Is(err, http.ErrNotSupported) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Subscribers returns the number of clients currently subscribed to the topic.
+func (h *SSEHub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.topics[topic]; ok {
+		return len(t.subscribers)
+	}
+	return 0
+}
+
+// Stats returns the metrics of every topic, sorted by topic name.
+func (h *SSEHub) Stats() []SSETopicStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make([]SSETopicStats, 0, len(h.topics))
+	for name, t := range h.topics {
+		stats = append(stats, SSETopicStats{
+			Topic:       name,
+			Subscribers: len(t.subscribers),
+			Published:   t.published,
+			Evicted:     t.evicted,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Topic < stats[j].Topic })
+	return stats
+}
+
+// subscribe registers a subscriber to the topics and returns the events published after lastEventID.
+// Both happen under the same lock so that no event is missed or sent twice.
+func (h *SSEHub) subscribe(topics []string, lastEventID string) (*sseSubscriber, []SSEEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &sseSubscriber{
+		events:  make(chan SSEEvent, h.opts.ClientBuffer),
+		evicted: make(chan struct{}),
+		topics:  topics,
+	}
+
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	replay := err == nil
+
+	var missed []SSEEvent
+	for _, name := range topics {
+		t := h.topic(name)
+		if _, ok := t.subscribers[sub]; ok {
+			continue
+		}
+		t.subscribers[sub] = struct{}{}
+
+		if !replay {
+			continue
+		}
+		for _, e := range t.replay {
+			if id, _ := strconv.ParseUint(e.ID, 10, 64); id > last {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	sort.SliceStable(missed, func(i, j int) bool {
+		a, _ := strconv.ParseUint(missed[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(missed[j].ID, 10, 64)
+		return a < b
+	})
+	return sub, missed
+}
+
+// unsubscribe removes the subscriber from all of its topics. The caller must hold h.mu.
+func (h *SSEHub) unsubscribe(sub *sseSubscriber) {
+	for _, name := range sub.topics {
+		if t, ok := h.topics[name]; ok {
+			delete(t.subscribers, sub)
+		}
+	}
+}
+
+// topic returns the named topic, creating it if required. The caller must hold h.mu.
+func (h *SSEHub) topic(name string) *sseTopic {
+	t, ok := h.topics[name]
+	if !ok {
+		t = &sseTopic{subscribers: map[*sseSubscriber]struct{}{}}
+		h.topics[name] = t
+	}
+	return t
+}
@@ -0,0 +1,151 @@
+package htmxheaders_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// readSSEEvents reads n events from the stream, returning the lines of each event.
+func readSSEEvents(t *testing.T, reader *bufio.Reader, n int) []string {
+	t.Helper()
+
+	var events []string
+	var current []string
+	for len(events) < n {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line != "" {
+			current = append(current, line)
+			continue
+		}
+		if len(current) > 0 {
+			events = append(events, strings.Join(current, "|"))
+			current = nil
+		}
+	}
+	return events
+}
+
+func waitForSubscribers(t *testing.T, hub *hh.SSEHub, topic string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return hub.Subscribers(topic) == n }, time.Second, time.Millisecond)
+}
+
+func TestSSEHubBroadcastsToSubscribers(t *testing.T) {
+	hub := hh.NewSSEHub(hh.SSEHubOptions{})
+	server := httptest.NewServer(hub.Handler("orders"))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	waitForSubscribers(t, hub, "orders", 1)
+	hub.Publish("orders", "newOrder", "<li>1</li>")
+	hub.Publish("other", "ignored", "<li>x</li>")
+	hub.Publish("orders", "newOrder", "<li>\n2\n</li>")
+
+	events := readSSEEvents(t, bufio.NewReader(resp.Body), 2)
+	assert.Equal(t, []string{
+		"id: 1|event: newOrder|data: <li>1</li>",
+		"id: 3|event: newOrder|data: <li>|data: 2|data: </li>",
+	}, events)
+}
+
+func TestSSEHubReplaysEventsAfterLastEventID(t *testing.T) {
+	hub := hh.NewSSEHub(hh.SSEHubOptions{ReplaySize: 2})
+	server := httptest.NewServer(hub.Handler("orders", "stock"))
+	defer server.Close()
+
+	hub.Publish("orders", "newOrder", "a")
+	hub.Publish("stock", "stockChanged", "b")
+	hub.Publish("orders", "newOrder", "c")
+	hub.Publish("orders", "newOrder", "d")
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Event 1 has been sent, and the orders topic only keeps events 3 and 4.
+	events := readSSEEvents(t, bufio.NewReader(resp.Body), 3)
+	assert.Equal(t, []string{
+		"id: 2|event: stockChanged|data: b",
+		"id: 3|event: newOrder|data: c",
+		"id: 4|event: newOrder|data: d",
+	}, events)
+}
+
+// blockingWriter is a flushable http.ResponseWriter whose Write blocks until released.
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	<-w.release
+	return len(b), nil
+}
+
+func TestSSEHubEvictsSlowConsumers(t *testing.T) {
+	hub := hh.NewSSEHub(hh.SSEHubOptions{ClientBuffer: 1})
+	w := &blockingWriter{ResponseRecorder: httptest.NewRecorder(), release: make(chan struct{})}
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	result := make(chan error)
+	go func() { result <- hub.Stream(w, r, "orders") }()
+	waitForSubscribers(t, hub, "orders", 1)
+
+	for i := 0; i < 5; i++ {
+		hub.Publish("orders", "newOrder", "<li></li>")
+	}
+	close(w.release)
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, hh.ErrSlowConsumer)
+	case <-time.After(time.Second):
+		t.Fatal("expected the slow consumer to be evicted")
+	}
+
+	assert.Equal(t, []hh.SSETopicStats{{Topic: "orders", Subscribers: 0, Published: 5, Evicted: 1}}, hub.Stats())
+}
+
+func TestSSEHubUnsubscribesWhenContextEnds(t *testing.T) {
+	hub := hh.NewSSEHub(hh.SSEHubOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+
+	result := make(chan error)
+	go func() { result <- hub.Stream(httptest.NewRecorder(), r, "orders") }()
+	waitForSubscribers(t, hub, "orders", 1)
+
+	cancel()
+	assert.NoError(t, <-result)
+	assert.Equal(t, 0, hub.Subscribers("orders"))
+}
+
+func TestSSEHubHandlerRespondsWithErrorWithoutFlushSupport(t *testing.T) {
+	hub := hh.NewSSEHub(hh.SSEHubOptions{})
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+
+	hub.Handler("orders").ServeHTTP(nonFlushingWriter{rec}, r)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotEqual(t, "text/event-stream", rec.Header().Get("Content-Type"))
+}
@@ -0,0 +1,88 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"time"
+)
+
+// statusWriter wraps a http.ResponseWriter, recording the status and size of the response
+// along with a copy of the headers as they were when the response was written.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+	header      http.Header
+	start       time.Time
+
+	// beforeWriteHeader, if set, is called just before the headers are written,
+	// while they can still be modified.
+	beforeWriteHeader func(status int)
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w, start: time.Now()}
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		sw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	// Informational responses are not final and may be followed by another status.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		sw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if sw.beforeWriteHeader != nil {
+		sw.beforeWriteHeader(code)
+	}
+	sw.wroteHeader = true
+	sw.status = code
+	sw.header = sw.Header().Clone()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.size += n
+	return n, err
+}
+
+// Flush flushes the underlying writer if it supports flushing, so that wrapped handlers
+// asserting http.Flusher continue to work.
+func (sw *statusWriter) Flush() {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// finish records the implicit 200 response sent by net/http when a handler returns without writing.
+// The headers are not written, leaving that to net/http, so that hijacked connections are not written to.
+func (sw *statusWriter) finish() {
+	if sw.wroteHeader {
+		return
+	}
+	if sw.beforeWriteHeader != nil {
+		sw.beforeWriteHeader(http.StatusOK)
+	}
+	sw.wroteHeader = true
+	sw.status = http.StatusOK
+	sw.header = sw.Header().Clone()
+}
+
+// elapsed returns the time since the writer was created.
+func (sw *statusWriter) elapsed() time.Duration {
+	return time.Since(sw.start)
+}
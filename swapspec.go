@@ -0,0 +1,152 @@
+package htmxheaders
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SwapSpec represents a complete hx-swap value: the swap method followed by any modifiers.
+// The zero value is equivalent to a plain SwapInnerHTML.
+// https://htmx.org/attributes/hx-swap/
+type SwapSpec struct {
+	Swap        Swap          // how the response will be swapped in relative to the target
+	Transition  bool          // use the View Transitions API for the swap
+	SwapDelay   time.Duration // the time to wait between clearing and swapping in the content
+	SettleDelay time.Duration // the time to wait between swapping in and settling the content
+	IgnoreTitle bool          // do not update the document title from a title element in the response
+	Scroll      string        // scroll the target, or the given selector, to "top" or "bottom", e.g. "top" or "#list:bottom"
+	Show        string        // scroll the target, or the given selector, into view, e.g. "top", "window:top" or "none"
+	FocusScroll *bool         // whether to scroll to focused elements after the swap, htmx's default is used if nil
+}
+
+// String returns the hx-swap representation of the SwapSpec, for use in the HX-Reswap header or
+// the hx-swap attribute, such as "outerHTML swap:500ms scroll:top".
+func (s SwapSpec) String() string {
+	parts := []string{s.Swap.String()}
+	if s.Transition {
+		parts = append(parts, "transition:true")
+	}
+	if s.SwapDelay > 0 {
+		parts = append(parts, "swap:"+formatInterval(s.SwapDelay))
+	}
+	if s.SettleDelay > 0 {
+		parts = append(parts, "settle:"+formatInterval(s.SettleDelay))
+	}
+	if s.IgnoreTitle {
+		parts = append(parts, "ignoreTitle:true")
+	}
+	if s.Scroll != "" {
+		parts = append(parts, "scroll:"+s.Scroll)
+	}
+	if s.Show != "" {
+		parts = append(parts, "show:"+s.Show)
+	}
+	if s.FocusScroll != nil {
+		parts = append(parts, "focus-scroll:"+strconv.FormatBool(*s.FocusScroll))
+	}
+	return strings.Join(parts, " ")
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the SwapSpec as its hx-swap representation.
+func (s SwapSpec) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseSwapSpec.
+func (s *SwapSpec) UnmarshalText(text []byte) error {
+	spec, err := ParseSwapSpec(string(text))
+	if err != nil {
+		return err
+	}
+	*s = spec
+	return nil
+}
+
+// ParseSwapSpec parses an hx-swap value, such as "outerHTML swap:500ms scroll:top", into a SwapSpec.
+// An error is returned for unknown swap methods or modifiers and invalid modifier values.
+func ParseSwapSpec(s string) (SwapSpec, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return SwapSpec{}, nil
+	}
+
+	var spec SwapSpec
+	if !strings.Contains(fields[0], ":") {
+		swap, err := SwapFromString(fields[0])
+		if err != nil {
+			return SwapSpec{}, err
+		}
+		spec.Swap = swap
+		fields = fields[1:]
+	}
+
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			return SwapSpec{}, fmt.Errorf("invalid swap modifier %q", field)
+		}
+
+		var err error
+		switch key {
+		case "transition":
+			spec.Transition, err = strconv.ParseBool(value)
+		case "swap":
+			spec.SwapDelay, err = parseInterval(value)
+		case "settle":
+			spec.SettleDelay, err = parseInterval(value)
+		case "ignoreTitle":
+			spec.IgnoreTitle, err = strconv.ParseBool(value)
+		case "scroll":
+			spec.Scroll = value
+		case "show":
+			spec.Show = value
+		case "focus-scroll":
+			var focusScroll bool
+			focusScroll, err = strconv.ParseBool(value)
+			spec.FocusScroll = &focusScroll
+		default:
+			err = fmt.Errorf("unknown modifier")
+		}
+		if err != nil {
+			return SwapSpec{}, fmt.Errorf("invalid swap modifier %q: %w", field, err)
+		}
+	}
+
+	return spec, nil
+}
+
+// ReswapSpec allows you to override how the response will be swapped, including any modifiers.
+// https://htmx.org/reference/#response_headers
+func ReswapSpec(spec SwapSpec) DecoratorFunction {
+	return AddCustomHeader("HX-Reswap", spec.String())
+}
+
+// formatInterval formats a duration as an htmx time interval, such as "1s" or "250ms".
+func formatInterval(d time.Duration) string {
+	if d%time.Second == 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// parseInterval parses an htmx time interval: a number followed by "ms", "s" or "m", or a plain number of milliseconds.
+func parseInterval(s string) (time.Duration, error) {
+	unit := time.Millisecond
+	number := s
+	switch {
+	case strings.HasSuffix(s, "ms"):
+		number = strings.TrimSuffix(s, "ms")
+	case strings.HasSuffix(s, "s"):
+		number, unit = strings.TrimSuffix(s, "s"), time.Second
+	case strings.HasSuffix(s, "m"):
+		number, unit = strings.TrimSuffix(s, "m"), time.Minute
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid time interval %q", s)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
@@ -0,0 +1,65 @@
+package htmxheaders_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestSwapSpecString(t *testing.T) {
+	focusScroll := false
+	tests := []struct {
+		spec     hh.SwapSpec
+		expected string
+	}{
+		{hh.SwapSpec{}, "innerHTML"},
+		{hh.SwapSpec{Swap: hh.SwapOuterHTML, Transition: true}, "outerHTML transition:true"},
+		{hh.SwapSpec{Swap: hh.SwapBeforeEnd, SwapDelay: time.Second, SettleDelay: 250 * time.Millisecond}, "beforeend swap:1s settle:250ms"},
+		{hh.SwapSpec{IgnoreTitle: true, Scroll: "#list:bottom", Show: "window:top"}, "innerHTML ignoreTitle:true scroll:#list:bottom show:window:top"},
+		{hh.SwapSpec{Swap: hh.SwapNone, FocusScroll: &focusScroll}, "none focus-scroll:false"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.spec.String())
+	}
+}
+
+func TestParseSwapSpec(t *testing.T) {
+	spec, err := hh.ParseSwapSpec("outerHTML swap:0.5s settle:100 transition:true scroll:#list:bottom focus-scroll:true")
+	require.NoError(t, err)
+
+	assert.Equal(t, hh.SwapOuterHTML, spec.Swap)
+	assert.Equal(t, 500*time.Millisecond, spec.SwapDelay)
+	assert.Equal(t, 100*time.Millisecond, spec.SettleDelay)
+	assert.True(t, spec.Transition)
+	assert.Equal(t, "#list:bottom", spec.Scroll)
+	require.NotNil(t, spec.FocusScroll)
+	assert.True(t, *spec.FocusScroll)
+}
+
+func TestParseSwapSpecModifiersOnly(t *testing.T) {
+	spec, err := hh.ParseSwapSpec("show:top")
+
+	require.NoError(t, err)
+	assert.Equal(t, hh.SwapSpec{Show: "top"}, spec)
+}
+
+func TestParseSwapSpecErrors(t *testing.T) {
+	for _, value := range []string{"sideways", "innerHTML swap:soon", "innerHTML wiggle:true", "innerHTML scroll"} {
+		_, err := hh.ParseSwapSpec(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestReswapSpec(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := hh.SetResponseHeaders(w, hh.ReswapSpec(hh.SwapSpec{Swap: hh.SwapAfterBegin, Show: "top"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "afterbegin show:top", w.Header().Get("HX-Reswap"))
+}
@@ -0,0 +1,107 @@
+package htmxheaders
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Switch is a http.Handler dispatching htmx requests to a handler chosen by the element making the request,
+// allowing several components on a page to share the same URL.
+//
+// Handlers are matched on the request's HX-Trigger (the id of the triggering element), then HX-Trigger-Name
+// (its name), then HX-Target (the id of the target element). Requests not made by htmx, and htmx requests
+// matching no handler, are served by the default handler, or receive a 404 Not Found if there is none.
+//
+// Example usage:
+//
+//	cart := hh.NewSwitch(cartPage)
+//	cart.HandleTarget("cart-items", cartItems)
+//	cart.HandleTriggerName("coupon", applyCoupon)
+//	mux.Handle("POST /cart", cart)
+type Switch struct {
+	mu             sync.RWMutex
+	defaultHandler http.Handler
+	byTrigger      map[string]http.Handler
+	byTriggerName  map[string]http.Handler
+	byTarget       map[string]http.Handler
+}
+
+// NewSwitch returns a Switch serving requests that match no other handler with defaultHandler, which may be nil.
+func NewSwitch(defaultHandler http.Handler) *Switch {
+	return &Switch{
+		defaultHandler: defaultHandler,
+		byTrigger:      map[string]http.Handler{},
+		byTriggerName:  map[string]http.Handler{},
+		byTarget:       map[string]http.Handler{},
+	}
+}
+
+// HandleTrigger registers the handler for htmx requests made by the element with the id, given with or without a leading "#".
+// As with http.ServeMux, HandleTrigger panics if a handler is already registered for the id.
+func (s *Switch) HandleTrigger(id string, handler http.Handler) {
+	s.handle(s.byTrigger, "HandleTrigger", strings.TrimPrefix(id, "#"), handler)
+}
+
+// HandleTriggerName registers the handler for htmx requests made by the element with the name.
+// As with http.ServeMux, HandleTriggerName panics if a handler is already registered for the name.
+func (s *Switch) HandleTriggerName(name string, handler http.Handler) {
+	s.handle(s.byTriggerName, "HandleTriggerName", name, handler)
+}
+
+// HandleTarget registers the handler for htmx requests targeting the element with the id, given with or without a leading "#".
+// As with http.ServeMux, HandleTarget panics if a handler is already registered for the id.
+func (s *Switch) HandleTarget(id string, handler http.Handler) {
+	s.handle(s.byTarget, "HandleTarget", strings.TrimPrefix(id, "#"), handler)
+}
+
+func (s *Switch) handle(handlers map[string]http.Handler, method, key string, handler http.Handler) {
+	if key == "" {
+		panic("htmxheaders: Switch." + method + " requires a non-empty key")
+	}
+	if handler == nil {
+		panic("htmxheaders: Switch." + method + " requires a non-nil handler")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := handlers[key]; exists {
+		panic("htmxheaders: Switch." + method + " called twice for " + key)
+	}
+	handlers[key] = handler
+}
+
+// Handler returns the handler for the request, or nil if no handler matches and there is no default handler.
+func (s *Switch) Handler(r *http.Request) http.Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	req := ParseRequest(r)
+	if req.Enabled {
+		candidates := []struct {
+			handlers map[string]http.Handler
+			key      string
+		}{
+			{s.byTrigger, req.Trigger},
+			{s.byTriggerName, req.TriggerName},
+			{s.byTarget, req.Target},
+		}
+		for _, c := range candidates {
+			if h, ok := c.handlers[c.key]; ok && c.key != "" {
+				return h
+			}
+		}
+	}
+	return s.defaultHandler
+}
+
+// ServeHTTP dispatches the request to the matching handler, adding the headers used to choose it to the Vary header.
+func (s *Switch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addVary(w.Header(), "HX-Request", "HX-Trigger", "HX-Trigger-Name", "HX-Target")
+	h := s.Handler(r)
+	if h == nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
@@ -0,0 +1,66 @@
+package htmxheaders_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func named(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, name)
+	})
+}
+
+func TestSwitch(t *testing.T) {
+	s := hh.NewSwitch(named("page"))
+	s.HandleTrigger("#apply-coupon", named("apply"))
+	s.HandleTriggerName("coupon", named("coupon"))
+	s.HandleTarget("cart-items", named("items"))
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"not htmx", map[string]string{"HX-Target": "cart-items"}, "page"},
+		{"trigger", map[string]string{"HX-Request": "true", "HX-Trigger": "apply-coupon", "HX-Trigger-Name": "coupon", "HX-Target": "cart-items"}, "apply"},
+		{"trigger name", map[string]string{"HX-Request": "true", "HX-Trigger-Name": "coupon", "HX-Target": "cart-items"}, "coupon"},
+		{"target", map[string]string{"HX-Request": "true", "HX-Trigger": "other", "HX-Target": "cart-items"}, "items"},
+		{"no match", map[string]string{"HX-Request": "true", "HX-Target": "summary"}, "page"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/cart", nil)
+		for name, value := range test.headers {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+
+		assert.Equal(t, test.expected, w.Body.String(), test.name)
+		assert.Equal(t, []string{"HX-Request", "HX-Trigger", "HX-Trigger-Name", "HX-Target"}, w.Header().Values("Vary"), test.name)
+	}
+}
+
+func TestSwitchWithoutDefault(t *testing.T) {
+	s := hh.NewSwitch(nil)
+	s.HandleTarget("cart-items", named("items"))
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/cart", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSwitchPanicsOnDuplicate(t *testing.T) {
+	s := hh.NewSwitch(nil)
+	s.HandleTarget("cart-items", named("items"))
+
+	assert.Panics(t, func() { s.HandleTarget("#cart-items", named("other")) })
+	assert.Panics(t, func() { s.HandleTrigger("", named("other")) })
+}
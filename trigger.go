@@ -1,7 +1,10 @@
 package htmxheaders
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -33,6 +36,7 @@ func (td TriggerDelay) String() string {
 // The when parameter specifies when the event should be triggered (e.g., immediately, after settle, after swap).
 // The eventName parameter specifies the name of the event(s) to be triggered.
 // Multiple event names can be provided, separated by commas.
+// To dispatch an event on a particular element, use TriggerWithDetail with a TriggerEvent Target.
 // https://htmx.org/headers/hx-trigger/
 func Trigger(when TriggerDelay, eventName ...string) DecoratorFunction {
 	events := strings.Join(eventName, ", ")
@@ -42,28 +46,165 @@ func Trigger(when TriggerDelay, eventName ...string) DecoratorFunction {
 // TriggerEvent represents an event that can be triggered with additional details.
 // https://htmx.org/headers/hx-trigger/
 type TriggerEvent struct {
-	Name   string // Name of the event.
-	Detail any    // Additional details associated with the event.
+	Name   string `json:"name"`             // Name of the event.
+	Detail any    `json:"detail,omitempty"` // Additional details associated with the event.
+	Target string `json:"target,omitempty"` // Optional CSS selector of the element the event is dispatched on, rather than the triggering element.
 }
 
+// ErrDuplicateTriggerEvent is returned by TriggerWithDetail when more than one event has the same name.
+// The events are sent as a JSON object keyed by name, so only one detail could be sent for each name.
+var ErrDuplicateTriggerEvent = errors.New("duplicate trigger event")
+
 // TriggerWithDetail creates a DecoratorFunction that adds an event JSON object to the response headers.
 // The JSON object contains a mapping of event names to their corresponding details.
 // The when parameter specifies when the event should be triggered (e.g., immediately, after settle, after swap).
 // The events parameter specifies a slice of TriggerEvent structs, each specifying an event name and its associated details.
+// The events are written in the order given, which is the order htmx dispatches them in.
+// An error wrapping ErrDuplicateTriggerEvent is returned if more than one event has the same name.
 // https://htmx.org/headers/hx-trigger/
 func TriggerWithDetail(when TriggerDelay, events ...TriggerEvent) DecoratorFunction {
 	return func(w http.ResponseWriter) error {
-		eventMap := map[string]interface{}{}
-		for _, event := range events {
-			eventMap[event.Name] = event.Detail
-		}
-
-		jsonData, err := json.Marshal(eventMap)
+		value, err := encodeTriggerEvents(events)
 		if err != nil {
 			return err
 		}
 
-		w.Header().Set(when.String(), string(jsonData))
+		w.Header().Set(when.String(), value)
 		return nil
 	}
 }
+
+// encodeTriggerEvents encodes the events as a JSON object, keeping the order of the events.
+func encodeTriggerEvents(events []TriggerEvent) (string, error) {
+	seen := make(map[string]bool, len(events))
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, event := range events {
+		if seen[event.Name] {
+			return "", fmt.Errorf("%w: %q", ErrDuplicateTriggerEvent, event.Name)
+		}
+		seen[event.Name] = true
+
+		name, err := json.Marshal(event.Name)
+		if err != nil {
+			return "", err
+		}
+		detail, err := encodeTriggerDetail(event)
+		if err != nil {
+			return "", err
+		}
+
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.Write(name)
+		sb.WriteString(":")
+		sb.Write(detail)
+	}
+	sb.WriteString("}")
+
+	return sb.String(), nil
+}
+
+// encodeTriggerDetail encodes the detail of the event, adding the target selector if it has one.
+// htmx reads the target from the detail object; details that are not objects are wrapped in an
+// object as its value, as htmx does itself.
+func encodeTriggerDetail(event TriggerEvent) ([]byte, error) {
+	detail, err := json.Marshal(event.Detail)
+	if err != nil || event.Target == "" {
+		return detail, err
+	}
+
+	if err := validateSelector(event.Target); err != nil {
+		return nil, fmt.Errorf("invalid target for trigger event %q: %w", event.Name, err)
+	}
+	target, err := json.Marshal(event.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	switch {
+	case event.Detail == nil:
+		return []byte(`{"target":` + string(target) + `}`), nil
+	case json.Unmarshal(detail, &fields) != nil:
+		return []byte(`{"value":` + string(detail) + `,"target":` + string(target) + `}`), nil
+	case fields["target"] != nil:
+		return nil, fmt.Errorf("trigger event %q sets a target, but its detail already has a target field", event.Name)
+	case len(fields) == 0:
+		return []byte(`{"target":` + string(target) + `}`), nil
+	default:
+		// Insert the target before the closing brace, keeping the order of the detail's fields.
+		detail = bytes.TrimSpace(detail)
+		return append(detail[:len(detail)-1], []byte(`,"target":`+string(target)+`}`)...), nil
+	}
+}
+
+// decodeTriggerDetail reverses encodeTriggerDetail, moving the target selector out of the detail.
+func decodeTriggerDetail(name string, detail any) TriggerEvent {
+	event := TriggerEvent{Name: name, Detail: detail}
+
+	fields, ok := detail.(map[string]any)
+	if !ok {
+		return event
+	}
+	target, ok := fields["target"].(string)
+	if !ok {
+		return event
+	}
+
+	event.Target = target
+	delete(fields, "target")
+	if value, ok := fields["value"]; ok && len(fields) == 1 {
+		event.Detail = value
+	} else if len(fields) == 0 {
+		event.Detail = nil
+	}
+	return event
+}
+
+// DecodeTriggerHeader decodes the value of a HX-Trigger, HX-Trigger-After-Settle or HX-Trigger-After-Swap
+// header into the events it triggers. Both a comma separated list of event names, as set by Trigger, and a
+// JSON object of event names and details, as set by TriggerWithDetail, are supported. The events are
+// returned in the order they appear in the header.
+func DecodeTriggerHeader(value string) ([]TriggerEvent, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(value, "{") {
+		var events []TriggerEvent
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				events = append(events, TriggerEvent{Name: name})
+			}
+		}
+		return events, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("error decoding trigger JSON: %w", err)
+	}
+
+	var events []TriggerEvent
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding trigger JSON: %w", err)
+		}
+
+		var detail any
+		if err := dec.Decode(&detail); err != nil {
+			return nil, fmt.Errorf("error decoding trigger JSON detail for event %q: %w", token, err)
+		}
+		events = append(events, decodeTriggerDetail(token.(string), detail))
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("error decoding trigger JSON: %w", err)
+	}
+	return events, nil
+}
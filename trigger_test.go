@@ -132,3 +132,132 @@ func TestTriggerWithDetailsHandlesMultipleEvents(t *testing.T) {
 	actualHeader := w.Header().Get("HX-Trigger")
 	require.JSONEq(t, expectedJSON, actualHeader)
 }
+
+func TestDecodeTriggerHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected []hh.TriggerEvent
+	}{
+		{"empty", "", nil},
+		{"single event", "event1", []hh.TriggerEvent{{Name: "event1"}}},
+		{"multiple events", "event1, event2,event3", []hh.TriggerEvent{{Name: "event1"}, {Name: "event2"}, {Name: "event3"}}},
+		{
+			"JSON events in order",
+			`{"zebra": "details", "apple": {"key": "value"}, "mango": null}`,
+			[]hh.TriggerEvent{
+				{Name: "zebra", Detail: "details"},
+				{Name: "apple", Detail: map[string]interface{}{"key": "value"}},
+				{Name: "mango", Detail: nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := hh.DecodeTriggerHeader(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, events)
+		})
+	}
+}
+
+func TestDecodeTriggerHeaderWithInvalidJSON(t *testing.T) {
+	_, err := hh.DecodeTriggerHeader(`{"event1": `)
+	assert.Error(t, err)
+}
+
+func TestTriggerWithDetailKeepsEventOrder(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := hh.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately,
+		hh.TriggerEvent{Name: "refreshList", Detail: []int{1, 2}},
+		hh.TriggerEvent{Name: "closeModal"},
+		hh.TriggerEvent{Name: "alert", Detail: map[string]string{"level": "info"}},
+	))
+	require.NoError(t, err)
+
+	expected := `{"refreshList":[1,2],"closeModal":null,"alert":{"level":"info"}}`
+	assert.Equal(t, expected, w.Header().Get("HX-Trigger"))
+}
+
+func TestTriggerWithDetailRejectsDuplicateEvents(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := hh.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately,
+		hh.TriggerEvent{Name: "toast", Detail: "first"},
+		hh.TriggerEvent{Name: "toast", Detail: "second"},
+	))
+
+	assert.ErrorIs(t, err, hh.ErrDuplicateTriggerEvent)
+	assert.Empty(t, w.Header().Get("HX-Trigger"))
+}
+
+func TestTriggerWithDetailTargetedEvents(t *testing.T) {
+	testCases := []struct {
+		testName string
+		event    hh.TriggerEvent
+		expected string
+	}{
+		{
+			testName: "Target without detail",
+			event:    hh.TriggerEvent{Name: "ping", Target: "#cart"},
+			expected: `{"ping":{"target":"#cart"}}`,
+		},
+		{
+			testName: "Target with object detail",
+			event:    hh.TriggerEvent{Name: "ping", Detail: struct{ B, A int }{B: 1, A: 2}, Target: "#cart"},
+			expected: `{"ping":{"B":1,"A":2,"target":"#cart"}}`,
+		},
+		{
+			testName: "Target with string detail",
+			event:    hh.TriggerEvent{Name: "ping", Detail: "hello", Target: "#cart"},
+			expected: `{"ping":{"value":"hello","target":"#cart"}}`,
+		},
+		{
+			testName: "Target with empty map detail",
+			event:    hh.TriggerEvent{Name: "ping", Detail: map[string]int{}, Target: "closest .card"},
+			expected: `{"ping":{"target":"closest .card"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			err := hh.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately, tc.event))
+			require.NoError(t, err)
+
+			header := w.Header().Get("HX-Trigger")
+			assert.Equal(t, tc.expected, header)
+
+			events, err := hh.DecodeTriggerHeader(header)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, tc.event.Target, events[0].Target)
+		})
+	}
+}
+
+func TestTriggerWithDetailRejectsInvalidTargets(t *testing.T) {
+	events := []hh.TriggerEvent{
+		{Name: "ping", Target: "#cart\n"},
+		{Name: "ping", Target: "input[name=x"},
+		{Name: "ping", Target: "#cart", Detail: map[string]string{"target": "#other"}},
+	}
+
+	for _, event := range events {
+		w := httptest.NewRecorder()
+		err := hh.SetResponseHeaders(w, hh.TriggerWithDetail(hh.TriggerImmediately, event))
+
+		assert.Error(t, err)
+		assert.Empty(t, w.Header().Get("HX-Trigger"))
+	}
+}
+
+func TestDecodeTriggerHeaderUnwrapsTargetedValues(t *testing.T) {
+	events, err := hh.DecodeTriggerHeader(`{"ping":{"value":"hello","target":"#cart"},"other":{"value":1}}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []hh.TriggerEvent{
+		{Name: "ping", Detail: "hello", Target: "#cart"},
+		{Name: "other", Detail: map[string]interface{}{"value": float64(1)}},
+	}, events)
+}
@@ -0,0 +1,284 @@
+package htmxheaders
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TriggerSpec represents an hx-trigger attribute value: one or more triggers, separated by commas,
+// each causing the element to make its request.
+// https://htmx.org/attributes/hx-trigger/
+type TriggerSpec []TriggerOn
+
+// TriggerOn represents a single trigger of an hx-trigger attribute, either an event with its modifiers,
+// such as "keyup changed delay:500ms", or polling, such as "every 2s".
+type TriggerOn struct {
+	Event     string        // the name of the event triggering the request, empty when polling
+	Every     time.Duration // the polling interval, used when Event is empty
+	Filter    string        // a JavaScript expression that must be true for the request to be made, without brackets
+	Once      bool          // only trigger the request once
+	Changed   bool          // only trigger the request if the value of the element has changed
+	Delay     time.Duration // wait before making the request, restarting if the event is seen again
+	Throttle  time.Duration // make the request at most once per interval
+	From      string        // listen for the event on another element, such as "document" or "closest form"
+	Target    string        // only trigger when the target of the event matches the CSS selector
+	Consume   bool          // prevent the event from triggering requests on parent elements
+	Queue     string        // how events are queued while a request is in flight: "first", "last", "all" or "none"
+	Root      string        // the root element of an intersect event
+	Threshold string        // the threshold of an intersect event, between 0.0 and 1.0
+}
+
+// extendedFromSelectors are the keywords of extended from: selectors that are followed by a CSS selector.
+var extendedFromSelectors = map[string]bool{"closest": true, "find": true, "next": true, "previous": true}
+
+// Every returns a TriggerOn polling with the given interval.
+func Every(interval time.Duration) TriggerOn {
+	return TriggerOn{Every: interval}
+}
+
+// On returns a TriggerOn for the event.
+func On(event string) TriggerOn {
+	return TriggerOn{Event: event}
+}
+
+// String returns the hx-trigger representation of the TriggerSpec, for use in templates.
+//
+// Example usage:
+//
+//	spec := hh.TriggerSpec{
+//	    {Event: "keyup", Changed: true, Delay: 500 * time.Millisecond},
+//	    {Event: "search"},
+//	}
+//	spec.String() // "keyup changed delay:500ms, search"
+func (s TriggerSpec) String() string {
+	triggers := make([]string, len(s))
+	for i, t := range s {
+		triggers[i] = t.String()
+	}
+	return strings.Join(triggers, ", ")
+}
+
+// Validate returns an error if any of the triggers is invalid.
+func (s TriggerSpec) Validate() error {
+	if len(s) == 0 {
+		return errors.New("trigger spec must have at least one trigger")
+	}
+	for _, t := range s {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the hx-trigger representation of the trigger, with modifiers in a fixed order.
+func (t TriggerOn) String() string {
+	var sb strings.Builder
+	if t.Event == "" {
+		sb.WriteString("every " + formatInterval(t.Every))
+	} else {
+		sb.WriteString(t.Event)
+	}
+	if t.Filter != "" {
+		sb.WriteString("[" + t.Filter + "]")
+	}
+
+	modifier := func(set bool, s string) {
+		if set {
+			sb.WriteString(" " + s)
+		}
+	}
+	modifier(t.Once, "once")
+	modifier(t.Changed, "changed")
+	modifier(t.Delay > 0, "delay:"+formatInterval(t.Delay))
+	modifier(t.Throttle > 0, "throttle:"+formatInterval(t.Throttle))
+	modifier(t.From != "", "from:"+t.From)
+	modifier(t.Target != "", "target:"+t.Target)
+	modifier(t.Consume, "consume")
+	modifier(t.Queue != "", "queue:"+t.Queue)
+	modifier(t.Root != "", "root:"+t.Root)
+	modifier(t.Threshold != "", "threshold:"+t.Threshold)
+	return sb.String()
+}
+
+// Validate returns an error if the trigger has no event or polling interval, or has an invalid modifier.
+func (t TriggerOn) Validate() error {
+	switch {
+	case t.Event == "" && t.Every <= 0:
+		return errors.New("trigger must have an event or a polling interval")
+	case strings.IndexFunc(t.Event, func(r rune) bool { return unicode.IsSpace(r) || strings.ContainsRune("[],", r) }) >= 0:
+		return fmt.Errorf("invalid trigger event %q", t.Event)
+	case t.Queue != "" && t.Queue != "first" && t.Queue != "last" && t.Queue != "all" && t.Queue != "none":
+		return fmt.Errorf("invalid trigger queue %q, expected first, last, all or none", t.Queue)
+	case t.Delay < 0 || t.Throttle < 0:
+		return errors.New("trigger delay and throttle must not be negative")
+	}
+	if t.Filter != "" {
+		if err := validateSelector(t.Filter); err != nil {
+			return fmt.Errorf("invalid trigger filter: %w", err)
+		}
+	}
+	if t.Target != "" {
+		if err := validateSelector(t.Target); err != nil {
+			return fmt.Errorf("invalid trigger target: %w", err)
+		}
+	}
+	if t.From != "" {
+		if err := validateSelector(t.From); err != nil {
+			return fmt.Errorf("invalid trigger from: %w", err)
+		}
+	}
+	return nil
+}
+
+// ParseTriggerSpec parses an hx-trigger attribute value, such as "keyup changed delay:500ms, search",
+// returning an error for unknown modifiers and invalid values.
+func ParseTriggerSpec(s string) (TriggerSpec, error) {
+	parts, err := splitTriggers(s)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := make(TriggerSpec, 0, len(parts))
+	for _, part := range parts {
+		t, err := parseTriggerOn(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trigger %q: %w", part, err)
+		}
+		spec = append(spec, t)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// splitTriggers splits the value on commas outside of filters, parentheses and quotes.
+func splitTriggers(s string) ([]string, error) {
+	var parts []string
+	var depth int
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '(':
+			depth++
+		case r == ']' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quote != 0 {
+		return nil, fmt.Errorf("trigger spec %q has an unterminated filter", s)
+	}
+	return append(parts, strings.TrimSpace(s[start:])), nil
+}
+
+// parseTriggerOn parses a single trigger.
+func parseTriggerOn(s string) (TriggerOn, error) {
+	var t TriggerOn
+
+	event := s
+	if i := strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '[' }); i >= 0 {
+		event = s[:i]
+	}
+	rest := strings.TrimLeftFunc(s[len(event):], unicode.IsSpace)
+
+	if event == "every" {
+		interval, remaining, _ := strings.Cut(rest, " ")
+		if i := strings.IndexByte(interval, '['); i >= 0 {
+			interval, remaining = interval[:i], interval[i:]+" "+remaining
+		}
+		every, err := parseInterval(interval)
+		if err != nil {
+			return TriggerOn{}, err
+		}
+		t.Every = every
+		rest = strings.TrimSpace(remaining)
+	} else {
+		t.Event = event
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		end := filterEnd(rest)
+		if end < 0 {
+			return TriggerOn{}, errors.New("unterminated filter")
+		}
+		t.Filter = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	tokens := strings.Fields(rest)
+	for i := 0; i < len(tokens); i++ {
+		key, value, hasValue := strings.Cut(tokens[i], ":")
+		var err error
+		switch {
+		case key == "once" && !hasValue:
+			t.Once = true
+		case key == "changed" && !hasValue:
+			t.Changed = true
+		case key == "consume" && !hasValue:
+			t.Consume = true
+		case key == "delay" && hasValue:
+			t.Delay, err = parseInterval(value)
+		case key == "throttle" && hasValue:
+			t.Throttle, err = parseInterval(value)
+		case key == "from" && hasValue:
+			if extendedFromSelectors[value] && i+1 < len(tokens) {
+				i++
+				value += " " + tokens[i]
+			}
+			t.From = value
+		case key == "target" && hasValue:
+			t.Target = value
+		case key == "queue" && hasValue:
+			t.Queue = value
+		case key == "root" && hasValue:
+			t.Root = value
+		case key == "threshold" && hasValue:
+			t.Threshold = value
+		default:
+			err = errors.New("unknown modifier")
+		}
+		if err != nil {
+			return TriggerOn{}, fmt.Errorf("invalid modifier %q: %w", tokens[i], err)
+		}
+	}
+
+	return t, nil
+}
+
+// filterEnd returns the index of the bracket closing the filter at the start of s, or -1 if it is not closed.
+func filterEnd(s string) int {
+	depth := 0
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
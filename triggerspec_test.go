@@ -0,0 +1,70 @@
+package htmxheaders_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+func TestTriggerSpecString(t *testing.T) {
+	tests := []struct {
+		spec     hh.TriggerSpec
+		expected string
+	}{
+		{hh.TriggerSpec{hh.On("click")}, "click"},
+		{hh.TriggerSpec{hh.Every(2 * time.Second)}, "every 2s"},
+		{hh.TriggerSpec{{Event: "keyup", Changed: true, Delay: 500 * time.Millisecond}, hh.On("search")}, "keyup changed delay:500ms, search"},
+		{hh.TriggerSpec{{Event: "click", Filter: "ctrlKey", Once: true, From: "closest form", Consume: true, Queue: "last"}}, "click[ctrlKey] once from:closest form consume queue:last"},
+		{hh.TriggerSpec{{Every: time.Second, Filter: "isActive()"}}, "every 1s[isActive()]"},
+		{hh.TriggerSpec{{Event: "intersect", Root: "#list", Threshold: "0.5", Throttle: time.Second, Target: ".row"}}, "intersect throttle:1s target:.row root:#list threshold:0.5"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.spec.String())
+	}
+}
+
+func TestParseTriggerSpec(t *testing.T) {
+	spec, err := hh.ParseTriggerSpec("keyup[key=='Enter' && !shiftKey, true] changed delay:1s from:closest form, every 30s [document.visibilityState === 'visible'], load once")
+	require.NoError(t, err)
+
+	assert.Equal(t, hh.TriggerSpec{
+		{Event: "keyup", Filter: "key=='Enter' && !shiftKey, true", Changed: true, Delay: time.Second, From: "closest form"},
+		{Every: 30 * time.Second, Filter: "document.visibilityState === 'visible'"},
+		{Event: "load", Once: true},
+	}, spec)
+}
+
+func TestParseTriggerSpecRoundTrip(t *testing.T) {
+	for _, value := range []string{
+		"click",
+		"every 2s",
+		"every 1s[isActive()]",
+		"keyup changed delay:500ms, search",
+		"sse:message target:#feed queue:all",
+		"click[ctrlKey] once throttle:250ms from:document consume",
+	} {
+		spec, err := hh.ParseTriggerSpec(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, value, spec.String())
+	}
+}
+
+func TestParseTriggerSpecErrors(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"click, ",
+		"click[ctrlKey",
+		"click delay:soon",
+		"click wiggle",
+		"click queue:sometimes",
+		"every",
+		"click from:#a[b",
+	} {
+		_, err := hh.ParseTriggerSpec(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,443 @@
+package htmxheaders
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// websocketGUID is the value appended to the Sec-WebSocket-Key when computing the Sec-WebSocket-Accept header.
+// https://www.rfc-editor.org/rfc/rfc6455#section-1.3
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes and close codes.
+// https://www.rfc-editor.org/rfc/rfc6455#section-5.2
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	WSCloseNormal        = 1000
+	WSCloseProtocolError = 1002
+	WSCloseInvalidData   = 1007
+	WSCloseTooLarge      = 1009
+)
+
+// ErrWSClosed is returned when reading from or writing to a WebSocket connection that has been closed.
+var ErrWSClosed = errors.New("websocket connection closed")
+
+// WSCloseError is returned by WSConn.ReadMessage when the client closes the connection.
+type WSCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WSCloseError) Error() string {
+	return fmt.Sprintf("websocket closed by client: %d %s", e.Code, e.Reason)
+}
+
+// WSUpgrader upgrades HTTP requests to WebSocket connections for use with the htmx WebSocket extension.
+// https://htmx.org/extensions/web-sockets/
+type WSUpgrader struct {
+	// CheckOrigin reports whether the request is allowed. If nil, requests with an Origin header
+	// are only allowed when the origin host matches the Host of the request.
+	CheckOrigin func(r *http.Request) bool
+
+	// MaxMessageSize is the maximum size in bytes of a message read from the client, defaults to 64KB.
+	MaxMessageSize int64
+}
+
+// Upgrade performs the WebSocket opening handshake and hijacks the connection.
+// If the handshake fails, an error response is written to w and an error is returned.
+//
+// Example usage:
+//
+//	var upgrader hh.WSUpgrader
+//	http.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+//	    conn, err := upgrader.Upgrade(w, r)
+//	    if err != nil {
+//	        return
+//	    }
+//	    defer conn.Close()
+//
+//	    for {
+//	        msg, err := conn.ReadRequest()
+//	        if err != nil {
+//	            return
+//	        }
+//	        fragment := hh.OOBFragment{Target: "#messages", Swap: hh.SwapBeforeEnd, HTML: renderMessage(msg.Values.Get("message"))}
+//	        if err := conn.WriteFragments(fragment); err != nil {
+//	            return
+//	        }
+//	    }
+//	})
+func (u WSUpgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*WSConn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "websocket: method must be GET", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: method must be GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket: not a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "websocket: unsupported version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "websocket: invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Key")
+	}
+
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "websocket: origin not allowed", http.StatusForbidden)
+		return nil, errors.New("websocket: origin not allowed")
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket: cannot hijack connection", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: cannot hijack connection: %w", err)
+	}
+
+	// The hijacked connection keeps any deadlines set from the server's ReadTimeout and WriteTimeout,
+	// which would otherwise close the WebSocket once they pass.
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: cannot clear connection deadlines: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	maxSize := u.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = 64 << 10
+	}
+
+	return &WSConn{conn: netConn, rw: rw, maxSize: maxSize}, nil
+}
+
+// WSMessage represents a message sent by the htmx WebSocket extension.
+// The extension sends the values of the triggering form along with the HTMX request headers.
+type WSMessage struct {
+	Request Request    // the HTMX request headers sent in the HEADERS object
+	Values  url.Values // the form values of the triggering element
+}
+
+// WSConn is a server-side WebSocket connection.
+// Writes are safe for concurrent use; reads must be made from a single goroutine.
+type WSConn struct {
+	conn    net.Conn
+	rw      *bufio.ReadWriter
+	maxSize int64
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// ReadMessage reads the next text or binary message, answering pings and reassembling fragmented messages.
+// A *WSCloseError is returned if the client closes the connection.
+func (c *WSConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	var started, text bool
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			closeErr := &WSCloseError{Code: WSCloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.closeWith(closeErr.Code, "")
+			return nil, closeErr
+		case wsOpText, wsOpBinary:
+			if started {
+				return nil, c.fail(WSCloseProtocolError, "websocket: expected continuation frame")
+			}
+			started = true
+			text = opcode == wsOpText
+		case wsOpContinuation:
+			if !started {
+				return nil, c.fail(WSCloseProtocolError, "websocket: unexpected continuation frame")
+			}
+		default:
+			return nil, c.fail(WSCloseProtocolError, fmt.Sprintf("websocket: unknown opcode %d", opcode))
+		}
+
+		if int64(len(message)+len(payload)) > c.maxSize {
+			return nil, c.fail(WSCloseTooLarge, "websocket: message too large")
+		}
+		message = append(message, payload...)
+
+		if fin {
+			if text && !utf8.Valid(message) {
+				return nil, c.fail(WSCloseInvalidData, "websocket: text message is not valid UTF-8")
+			}
+			return message, nil
+		}
+	}
+}
+
+// ReadRequest reads the next message and parses it as sent by the htmx WebSocket extension.
+func (c *WSConn) ReadRequest() (*WSMessage, error) {
+	data, err := c.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return ParseWSMessage(data)
+}
+
+// ParseWSMessage parses the JSON message sent by the htmx WebSocket extension into a WSMessage.
+// Values that are not strings, such as numbers from hx-vals, are kept in their JSON representation.
+func ParseWSMessage(data []byte) (*WSMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error unmarshalling websocket message JSON: %w", err)
+	}
+
+	msg := &WSMessage{Values: url.Values{}}
+	for name, raw := range fields {
+		if name != "HEADERS" {
+			msg.Values[name] = wsValues(raw)
+			continue
+		}
+
+		var headers map[string]*string
+		if err := json.Unmarshal(raw, &headers); err != nil {
+			return nil, fmt.Errorf("error unmarshalling websocket message HEADERS: %w", err)
+		}
+
+		h := http.Header{}
+		for key, value := range headers {
+			if value != nil {
+				h.Set(key, *value)
+			}
+		}
+		msg.Request = parseRequestHeader(h)
+	}
+
+	return msg, nil
+}
+
+// WriteText sends a text message.
+func (c *WSConn) WriteText(text string) error {
+	return c.writeFrame(wsOpText, []byte(text))
+}
+
+// WriteFragments sends a single message containing each of the out of band fragments.
+func (c *WSConn) WriteFragments(fragments ...OOBFragment) error {
+	var buf bytes.Buffer
+	if err := RenderOOB(&buf, fragments...); err != nil {
+		return err
+	}
+	return c.writeFrame(wsOpText, buf.Bytes())
+}
+
+// Close sends a normal close frame and closes the underlying connection.
+func (c *WSConn) Close() error {
+	return c.closeWith(WSCloseNormal, "")
+}
+
+func (c *WSConn) fail(code int, reason string) error {
+	c.closeWith(code, reason)
+	return errors.New(reason)
+}
+
+func (c *WSConn) closeWith(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	writeErr := c.writeFrameLocked(wsOpClose, payload)
+	closeErr := c.conn.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+	return closeErr
+}
+
+func (c *WSConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(WSCloseProtocolError, "websocket: reserved bits set")
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(WSCloseProtocolError, "websocket: client frames must be masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= wsOpClose && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(WSCloseProtocolError, "websocket: invalid control frame")
+	}
+	if length > uint64(c.maxSize) {
+		return false, 0, nil, c.fail(WSCloseTooLarge, "websocket: message too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *WSConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrWSClosed
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+// writeFrameLocked writes a single unmasked frame. The caller must hold c.mu.
+func (c *WSConn) writeFrameLocked(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// wsValues converts a JSON form value into its string values.
+func wsValues(raw json.RawMessage) []string {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			values = append(values, s)
+		} else if string(item) != "null" {
+			values = append(values, string(item))
+		}
+	}
+	return values
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,256 @@
+package htmxheaders_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hh "github.com/thisisthemurph/htmxheaders"
+)
+
+// dialWebSocket performs the opening handshake against the server, returning the client connection.
+func dialWebSocket(t *testing.T, server *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	request := "GET / HTTP/1.1\r\n" +
+		"Host: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	_, err = conn.Write([]byte(request))
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	return conn, reader
+}
+
+// writeClientFrame writes a masked frame, as required for frames sent by a client.
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := conn.Write(frame)
+	require.NoError(t, err)
+}
+
+// readServerFrame reads a single unmasked frame sent by the server.
+func readServerFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	t.Helper()
+
+	var header [2]byte
+	_, err := io.ReadFull(reader, header[:])
+	require.NoError(t, err)
+
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		_, err := io.ReadFull(reader, ext[:])
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(reader, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocketEchoesHTMXMessages(t *testing.T) {
+	received := make(chan *hh.WSMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := hh.WSUpgrader{}.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		msg, err := conn.ReadRequest()
+		if err != nil {
+			return
+		}
+		received <- msg
+
+		_ = conn.WriteFragments(hh.OOBFragment{Target: "#messages", Swap: hh.SwapBeforeEnd, HTML: "<li>" + msg.Values.Get("message") + "</li>"})
+		_, _ = conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	conn, reader := dialWebSocket(t, server)
+	defer conn.Close()
+
+	message := `{"message":"hello","tags":["a","b"],"count":3,"HEADERS":{"HX-Request":"true","HX-Trigger":"chat-form","HX-Trigger-Name":null,"HX-Target":"chat-form","HX-Current-URL":"http://example.com/chat"}}`
+	writeClientFrame(t, conn, false, 0x1, []byte(message[:10]))
+	writeClientFrame(t, conn, true, 0x9, []byte("ping"))
+	writeClientFrame(t, conn, true, 0x0, []byte(message[10:]))
+
+	opcode, payload := readServerFrame(t, reader)
+	assert.Equal(t, byte(0xA), opcode)
+	assert.Equal(t, "ping", string(payload))
+
+	msg := <-received
+	assert.Equal(t, hh.Request{Enabled: true, Trigger: "chat-form", Target: "chat-form", CurrentURL: "http://example.com/chat"}, msg.Request)
+	assert.Equal(t, "hello", msg.Values.Get("message"))
+	assert.Equal(t, []string{"a", "b"}, msg.Values["tags"])
+	assert.Equal(t, "3", msg.Values.Get("count"))
+
+	opcode, payload = readServerFrame(t, reader)
+	assert.Equal(t, byte(0x1), opcode)
+	assert.Equal(t, `<div hx-swap-oob="beforeend:#messages"><li>hello</li></div>`, string(payload))
+
+	writeClientFrame(t, conn, true, 0x8, []byte{0x03, 0xE8})
+	opcode, payload = readServerFrame(t, reader)
+	assert.Equal(t, byte(0x8), opcode)
+	assert.Equal(t, []byte{0x03, 0xE8}, payload)
+}
+
+func TestWebSocketRejectsUnmaskedFrames(t *testing.T) {
+	result := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := hh.WSUpgrader{}.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		_, err = conn.ReadMessage()
+		result <- err
+	}))
+	defer server.Close()
+
+	conn, reader := dialWebSocket(t, server)
+	defer conn.Close()
+
+	_, err := conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+	require.NoError(t, err)
+
+	assert.Error(t, <-result)
+	opcode, payload := readServerFrame(t, reader)
+	assert.Equal(t, byte(0x8), opcode)
+	assert.Equal(t, uint16(hh.WSCloseProtocolError), binary.BigEndian.Uint16(payload))
+}
+
+func TestWebSocketReportsClientClose(t *testing.T) {
+	result := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := hh.WSUpgrader{}.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		_, err = conn.ReadMessage()
+		result <- err
+	}))
+	defer server.Close()
+
+	conn, _ := dialWebSocket(t, server)
+	defer conn.Close()
+
+	writeClientFrame(t, conn, true, 0x8, append([]byte{0x03, 0xE9}, "bye"...))
+
+	var closeErr *hh.WSCloseError
+	require.True(t, errors.As(<-result, &closeErr))
+	assert.Equal(t, 1001, closeErr.Code)
+	assert.Equal(t, "bye", closeErr.Reason)
+}
+
+func TestWebSocketUpgradeRejectsInvalidHandshakes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"not an upgrade", map[string]string{}, http.StatusBadRequest},
+		{"wrong version", map[string]string{"Sec-WebSocket-Version": "8"}, http.StatusUpgradeRequired},
+		{"missing key", map[string]string{"Sec-WebSocket-Key": ""}, http.StatusBadRequest},
+		{"cross origin", map[string]string{"Origin": "http://evil.example.com"}, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com/chat", nil)
+			if tc.name != "not an upgrade" {
+				r.Header.Set("Connection", "Upgrade")
+				r.Header.Set("Upgrade", "websocket")
+				r.Header.Set("Sec-WebSocket-Version", "13")
+				r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			}
+			for key, value := range tc.headers {
+				r.Header.Set(key, value)
+			}
+
+			w := httptest.NewRecorder()
+			conn, err := hh.WSUpgrader{}.Upgrade(w, r)
+
+			assert.Error(t, err)
+			assert.Nil(t, conn)
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
+
+func TestParseWSMessageRejectsInvalidJSON(t *testing.T) {
+	_, err := hh.ParseWSMessage([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestWebSocketOutlivesServerTimeouts(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := hh.WSUpgrader{}.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.WriteText(string(msg))
+	}))
+	server.Config.ReadTimeout = 50 * time.Millisecond
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	conn, reader := dialWebSocket(t, server)
+	defer conn.Close()
+
+	time.Sleep(150 * time.Millisecond)
+	writeClientFrame(t, conn, true, 0x1, []byte("still open"))
+
+	opcode, payload := readServerFrame(t, reader)
+	assert.Equal(t, byte(0x1), opcode)
+	assert.Equal(t, "still open", string(payload))
+}